@@ -0,0 +1,223 @@
+// Package agent provides a local session agent, similar to ssh-agent. The agent
+// logs into the device once, keeps the session alive and hands it out to other
+// processes over a Unix domain socket, so the password stays in a single process.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/auth"
+)
+
+const (
+	opSession = "session"
+
+	connTimeout = 30 * time.Second
+)
+
+type request struct {
+	Op string `json:"op"`
+}
+
+type response struct {
+	Address  string        `json:"address,omitempty"`
+	Username string        `json:"username,omitempty"`
+	Session  *auth.Session `json:"session,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// DefaultSocketPath returns the socket location in $XDG_RUNTIME_DIR or,
+// if not set, a per-user location in the temporary directory.
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "go-fritzos-agent.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("go-fritzos-agent-%d.sock", os.Getuid()))
+}
+
+// Server hands out the session of its client to the processes connecting to it.
+type Server struct {
+	client *fritzos.Client
+
+	mu       sync.Mutex
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// NewServer creates an agent for c, which must be logged in already.
+// Use c.KeepAlive to prevent the session from expiring between requests.
+func NewServer(c *fritzos.Client) *Server {
+	return &Server{client: c}
+}
+
+// ListenAndServe listens on the Unix socket at path, which only the current user
+// can access, and serves requests until Close is called.
+func (s *Server) ListenAndServe(path string) error {
+	// Remove a socket left over by a previous agent
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l until Close is called.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				s.wg.Wait()
+				return nil
+			}
+			return err
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// Close stops accepting connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(connTimeout))
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+
+		if err := enc.Encode(s.serve(req)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) serve(req request) *response {
+	switch req.Op {
+	case opSession:
+		ctx, cancel := context.WithTimeout(context.Background(), s.client.Timeouts().Request)
+		defer cancel()
+
+		// Hand out only sessions, which the device still accepts
+		if err := s.client.Refresh(ctx); err != nil {
+			return &response{Error: err.Error()}
+		}
+
+		return &response{
+			Address:  s.client.Address(),
+			Username: s.client.Username(),
+			Session:  s.client.Session(),
+		}
+	}
+
+	return &response{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+}
+
+// Client requests sessions from an agent. It implements auth.SessionStore,
+// so it can be plugged into fritzos.Client.WithSessionStore.
+type Client struct {
+	path string
+}
+
+// NewClient creates a client for the agent listening at path.
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// Session returns the session held by the agent, with the address and user it belongs to.
+func (c *Client) Session(ctx context.Context) (address, username string, s *auth.Session, err error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("couldn't connect to agent, %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(conn).Encode(&request{Op: opSession}); err != nil {
+		return "", "", nil, err
+	}
+
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return "", "", nil, err
+	}
+
+	if resp.Error != "" {
+		return "", "", nil, fmt.Errorf("agent: %s", resp.Error)
+	}
+
+	return resp.Address, resp.Username, resp.Session, nil
+}
+
+// Load returns the agent's session, if it belongs to the device at address and
+// username (an empty username matches any user).
+func (c *Client) Load(address, username string) (*auth.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connTimeout)
+	defer cancel()
+
+	addr, user, s, err := c.Session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if addr != address || (username != "" && user != username) {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// Save does nothing, the agent owns its session.
+func (c *Client) Save(_, _ string, _ *auth.Session) error {
+	return nil
+}
+
+// Delete does nothing, the agent renews its session on the next request.
+func (c *Client) Delete(_, _ string) error {
+	return nil
+}
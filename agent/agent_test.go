@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	fritzos "github.com/rumenvasilev/go-fritzos"
+)
+
+func loginServer(logins *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sid := "0000000000000000"
+		switch {
+		case r.Method == http.MethodPost && r.FormValue("response") != "":
+			*logins++
+			sid = "2c21f7f4f060848e"
+		case r.URL.Query().Get("sid") == "2c21f7f4f060848e":
+			sid = "2c21f7f4f060848e"
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, "<SessionInfo><SID>%s</SID><Challenge>1234567z</Challenge><BlockTime>0</BlockTime></SessionInfo>", sid)
+	}))
+}
+
+func TestAgent(t *testing.T) {
+	is := is.New(t)
+
+	var logins int
+	ts := loginServer(&logins)
+	defer ts.Close()
+
+	c := fritzos.New().WithAddress(ts.URL).WithHTTPClient(ts.Client())
+	is.NoErr(c.Login("user", "password"))
+
+	// Unix socket paths are limited in length, so t.TempDir() might be too long
+	dir, err := os.MkdirTemp("", "agent")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent.sock")
+
+	srv := NewServer(c)
+	served := make(chan error)
+	go func() { served <- srv.ListenAndServe(path) }()
+	defer func() {
+		is.NoErr(srv.Close())
+		is.NoErr(<-served)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for {
+		if _, err := os.Stat(path); err == nil || ctx.Err() != nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	fi, err := os.Stat(path)
+	is.NoErr(err)
+	is.Equal(fi.Mode().Perm(), os.FileMode(0600))
+
+	t.Run("session", func(t *testing.T) {
+		addr, user, s, err := NewClient(path).Session(ctx)
+		is.NoErr(err)
+		is.Equal(addr, ts.URL)
+		is.Equal(user, "user")
+		is.Equal(s.String(), "2c21f7f4f060848e")
+	})
+
+	t.Run("session store", func(t *testing.T) {
+		other := fritzos.New().WithAddress(ts.URL).WithHTTPClient(ts.Client()).WithSessionStore(NewClient(path))
+		is.NoErr(other.Resume(ctx, ""))
+		is.Equal(other.Session().String(), "2c21f7f4f060848e")
+		is.NoErr(other.Close()) // keeps the agent's session
+		is.Equal(logins, 1)
+	})
+
+	t.Run("other device", func(t *testing.T) {
+		s, err := NewClient(path).Load("http://192.168.178.1", "")
+		is.NoErr(err)
+		is.Equal(s, nil)
+	})
+}
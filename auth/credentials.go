@@ -0,0 +1,361 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// Credentials are the username and password used to authenticate.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialProvider supplies the credentials for the device at address,
+// so passwords don't have to be passed on the command line.
+type CredentialProvider interface {
+	// Credentials returns ErrCredentialsNotFound, if the provider has no credentials for address.
+	Credentials(ctx context.Context, address string) (*Credentials, error)
+}
+
+// ChainProvider asks its providers in order and returns the first credentials found.
+type ChainProvider []CredentialProvider
+
+func (c ChainProvider) Credentials(ctx context.Context, address string) (*Credentials, error) {
+	for _, p := range c {
+		creds, err := p.Credentials(ctx, address)
+		if errors.Is(err, ErrCredentialsNotFound) {
+			continue
+		}
+		return creds, err
+	}
+
+	return nil, ErrCredentialsNotFound
+}
+
+const (
+	DefaultUsernameEnv = "FRITZ_USERNAME"
+	DefaultPasswordEnv = "FRITZ_PASSWORD"
+)
+
+// EnvProvider reads the credentials from environment variables.
+// Empty variable names default to FRITZ_USERNAME and FRITZ_PASSWORD.
+type EnvProvider struct {
+	UsernameVar string
+	PasswordVar string
+}
+
+func (e *EnvProvider) Credentials(_ context.Context, _ string) (*Credentials, error) {
+	userVar, passVar := e.UsernameVar, e.PasswordVar
+	if userVar == "" {
+		userVar = DefaultUsernameEnv
+	}
+	if passVar == "" {
+		passVar = DefaultPasswordEnv
+	}
+
+	password, ok := os.LookupEnv(passVar)
+	if !ok || password == "" {
+		return nil, ErrCredentialsNotFound
+	}
+
+	return &Credentials{
+		Username: os.Getenv(userVar),
+		Password: password,
+	}, nil
+}
+
+// NetrcProvider reads the credentials of the device's host from a .netrc file:
+//
+//	machine fritz.box login fritz1234 password secret
+//
+// An empty Path defaults to $NETRC or ~/.netrc.
+type NetrcProvider struct {
+	Path string
+}
+
+func (n *NetrcProvider) Credentials(_ context.Context, address string) (*Credentials, error) {
+	path, err := n.path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrCredentialsNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := parseNetrc(string(data), u.Hostname())
+	if creds == nil || creds.Password == "" {
+		return nil, ErrCredentialsNotFound
+	}
+
+	return creds, nil
+}
+
+func (n *NetrcProvider) path() (string, error) {
+	if n.Path != "" {
+		return n.Path, nil
+	}
+
+	if p := os.Getenv("NETRC"); p != "" {
+		return p, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".netrc"), nil
+}
+
+// parseNetrc returns the credentials of the machine entry for host, falling back
+// to the default entry.
+func parseNetrc(data, host string) *Credentials {
+	var found, fallback, current *Credentials
+
+	fields := strings.Fields(data)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			current = nil
+			if i+1 < len(fields) {
+				i++
+				if fields[i] == host && found == nil {
+					found = &Credentials{}
+					current = found
+				}
+			}
+		case "default":
+			current = nil
+			if fallback == nil {
+				fallback = &Credentials{}
+				current = fallback
+			}
+		case "login":
+			if i+1 < len(fields) {
+				i++
+				if current != nil {
+					current.Username = fields[i]
+				}
+			}
+		case "password":
+			if i+1 < len(fields) {
+				i++
+				if current != nil {
+					current.Password = fields[i]
+				}
+			}
+		}
+	}
+
+	if found != nil {
+		return found
+	}
+	return fallback
+}
+
+// PromptProvider asks for the credentials on the terminal, without echoing the password.
+// Username is asked for only if empty, an empty answer selects the user last logged in.
+// In and Out default to os.Stdin and os.Stderr.
+type PromptProvider struct {
+	Username string
+	In       *os.File
+	Out      io.Writer
+}
+
+func (p *PromptProvider) Credentials(_ context.Context, address string) (*Credentials, error) {
+	in, out := p.In, p.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+
+	if !term.IsTerminal(int(in.Fd())) {
+		return nil, ErrCredentialsNotFound
+	}
+
+	creds := &Credentials{Username: p.Username}
+	if creds.Username == "" {
+		fmt.Fprintf(out, "Username for %s (empty for the last user): ", address)
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		creds.Username = strings.TrimSpace(line)
+	}
+
+	fmt.Fprintf(out, "Password for %s: ", address)
+	password, err := term.ReadPassword(int(in.Fd()))
+	fmt.Fprintln(out)
+	if err != nil {
+		return nil, err
+	}
+	creds.Password = string(password)
+
+	return creds, nil
+}
+
+// EncryptedFileProvider reads the credentials from a file encrypted at rest with
+// a key derived from a passphrase (scrypt, AES-GCM). Use SaveEncryptedCredentials
+// to create or update the file.
+type EncryptedFileProvider struct {
+	Path string
+	// Passphrase is called, when the file needs to be decrypted.
+	Passphrase func() ([]byte, error)
+}
+
+func (e *EncryptedFileProvider) Credentials(_ context.Context, address string) (*Credentials, error) {
+	data, err := os.ReadFile(e.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrCredentialsNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := e.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := decryptCredentials(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, ok := all[address]
+	if !ok {
+		return nil, ErrCredentialsNotFound
+	}
+	return creds, nil
+}
+
+// SaveEncryptedCredentials adds or replaces the credentials of the device at address
+// in the encrypted file at path, which is created with permissions 0600 if needed.
+func SaveEncryptedCredentials(path string, passphrase []byte, address string, creds *Credentials) error {
+	all := make(map[string]*Credentials)
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		all, err = decryptCredentials(data, passphrase)
+		if err != nil {
+			return err
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	all[address] = creds
+
+	data, err = encryptCredentials(all, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// deriveKey derives the AES key from the passphrase with the recommended scrypt parameters.
+func deriveKey(passphrase, salt []byte) ([]byte, error) {
+	return scrypt.Key(passphrase, salt, 32768, 8, 1, keySize)
+}
+
+// encryptCredentials returns salt | nonce | ciphertext of the JSON encoded credentials.
+func encryptCredentials(all map[string]*Credentials, passphrase []byte) ([]byte, error) {
+	plain, err := json.Marshal(all)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(salt, nonce...)
+	return gcm.Seal(out, nonce, plain, nil), nil
+}
+
+func decryptCredentials(data, passphrase []byte) (map[string]*Credentials, error) {
+	if len(data) < saltSize {
+		return nil, ErrCredentialsCorrupt
+	}
+
+	gcm, err := newGCM(passphrase, data[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[saltSize:]
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrCredentialsCorrupt
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrCredentialsCorrupt
+	}
+
+	var all map[string]*Credentials
+	err = json.Unmarshal(plain, &all)
+	return all, err
+}
+
+func newGCM(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestEnvProvider(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	t.Setenv(DefaultUsernameEnv, "fritz1234")
+	t.Setenv(DefaultPasswordEnv, "secret")
+	creds, err := (&EnvProvider{}).Credentials(ctx, Address)
+	is.NoErr(err)
+	is.Equal(creds, &Credentials{Username: "fritz1234", Password: "secret"})
+
+	_, err = (&EnvProvider{PasswordVar: "FRITZ_TEST_UNSET"}).Credentials(ctx, Address)
+	is.True(errors.Is(err, ErrCredentialsNotFound))
+}
+
+func TestNetrcProvider(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), ".netrc")
+	err := os.WriteFile(path, []byte(`machine example.com login other password other
+machine fritz.box
+	login fritz1234
+	password secret
+default login anonymous password guest
+`), 0600)
+	is.NoErr(err)
+
+	p := &NetrcProvider{Path: path}
+	creds, err := p.Credentials(ctx, "http://fritz.box")
+	is.NoErr(err)
+	is.Equal(creds, &Credentials{Username: "fritz1234", Password: "secret"})
+
+	creds, err = p.Credentials(ctx, "https://192.168.178.1:443")
+	is.NoErr(err)
+	is.Equal(creds, &Credentials{Username: "anonymous", Password: "guest"})
+
+	_, err = (&NetrcProvider{Path: filepath.Join(t.TempDir(), "missing")}).Credentials(ctx, Address)
+	is.True(errors.Is(err, ErrCredentialsNotFound))
+}
+
+func TestEncryptedFileProvider(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "credentials")
+	want := &Credentials{Username: "fritz1234", Password: "secret"}
+	is.NoErr(SaveEncryptedCredentials(path, []byte("passphrase"), Address, want))
+	is.NoErr(SaveEncryptedCredentials(path, []byte("passphrase"), "http://192.168.178.1", &Credentials{Password: "other"}))
+
+	fi, err := os.Stat(path)
+	is.NoErr(err)
+	is.Equal(fi.Mode().Perm(), os.FileMode(0600))
+
+	data, err := os.ReadFile(path)
+	is.NoErr(err)
+	is.True(!bytes.Contains(data, []byte("secret"))) // encrypted at rest
+
+	p := &EncryptedFileProvider{Path: path, Passphrase: func() ([]byte, error) { return []byte("passphrase"), nil }}
+	creds, err := p.Credentials(ctx, Address)
+	is.NoErr(err)
+	is.Equal(creds, want)
+
+	_, err = p.Credentials(ctx, "http://unknown")
+	is.True(errors.Is(err, ErrCredentialsNotFound))
+
+	p.Passphrase = func() ([]byte, error) { return []byte("wrong"), nil }
+	_, err = p.Credentials(ctx, Address)
+	is.True(errors.Is(err, ErrCredentialsCorrupt))
+}
+
+func TestChainProvider(t *testing.T) {
+	is := is.New(t)
+
+	t.Setenv("FRITZ_TEST_PASSWORD", "secret")
+	chain := ChainProvider{
+		&NetrcProvider{Path: filepath.Join(t.TempDir(), "missing")},
+		&EnvProvider{PasswordVar: "FRITZ_TEST_PASSWORD"},
+	}
+	creds, err := chain.Credentials(context.Background(), Address)
+	is.NoErr(err)
+	is.Equal(creds.Password, "secret")
+
+	// a prompt without terminal is skipped
+	r, w, err := os.Pipe()
+	is.NoErr(err)
+	defer r.Close()
+	defer w.Close()
+
+	chain = ChainProvider{&PromptProvider{In: r, Out: w}}
+	_, err = chain.Credentials(context.Background(), Address)
+	is.True(errors.Is(err, ErrCredentialsNotFound))
+}
@@ -9,6 +9,9 @@ var (
 	ErrSessionInvalid           = errSessionInvalid()
 	ErrInvalidHeaderContentType = errInvalidHeaderContentType()
 	ErrUnsupportedChallenge     = errUnsupportedChallenge()
+	ErrSessionExpired           = errSessionExpired()
+	ErrCredentialsNotFound      = errCredentialsNotFound()
+	ErrCredentialsCorrupt       = errCredentialsCorrupt()
 )
 
 func errSessionInvalid() error {
@@ -23,6 +26,18 @@ func errUnsupportedChallenge() error {
 	return errors.New("cannot solve challenge, input string is not in the expected format")
 }
 
+func errSessionExpired() error {
+	return errors.New("session id is expired or not valid anymore")
+}
+
+func errCredentialsNotFound() error {
+	return errors.New("no credentials found for the device")
+}
+
+func errCredentialsCorrupt() error {
+	return errors.New("cannot decrypt credentials, wrong passphrase or corrupt file")
+}
+
 type BlockTimeError struct {
 	Duration int
 	Message  string
@@ -23,12 +23,17 @@ import (
 const (
 	Address   = "http://fritz.box"
 	loginPath = "login_sid.lua?version=2"
+	emptySID  = "0000000000000000"
 )
 
-type Session string
+// Session is an authenticated session on the device.
+type Session struct {
+	ID     string
+	Rights Rights
+}
 
 func (s *Session) String() string {
-	return string(*s)
+	return s.ID
 }
 
 // Close will logout from the authenticated device.
@@ -37,18 +42,43 @@ func (s *Session) Close() error {
 	return s.CloseWithAddress(Address)
 }
 
-func (s *Session) CloseWithAddress(address string) error {
-	fullAddress := fmt.Sprintf("%s/%s", address, loginPath)
-	p := url.Values{}
-	p.Set("logout", s.String())
+// CloseContext is the same as Close, but accepts context.
+func (s *Session) CloseContext(ctx context.Context) error {
+	return s.CloseWithAddressContext(ctx, Address)
+}
 
+func (s *Session) CloseWithAddress(address string) error {
 	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(30)*time.Second)
 	defer cancel()
 
-	resp, err := request.GenericPostRequestWithContext(rctx, fullAddress, strings.NewReader(p.Encode()))
-	if err != nil || resp.StatusCode != http.StatusOK {
+	return s.CloseWithAddressContext(rctx, address)
+}
+
+// CloseWithAddressContext is the same as CloseWithAddress, but accepts context.
+func (s *Session) CloseWithAddressContext(ctx context.Context, address string) error {
+	return s.CloseWithClient(ctx, request.DefaultClient, address)
+}
+
+// CloseWithClient will logout from the device at address, sending the request
+// through the provided client.
+func (s *Session) CloseWithClient(ctx context.Context, c *request.Client, address string) error {
+	fullAddress, err := request.JoinURL(address, loginPath)
+	if err != nil {
+		return err
+	}
+
+	p := url.Values{}
+	p.Set("logout", s.String())
+
+	resp, err := c.PostWithContext(ctx, fullAddress, strings.NewReader(p.Encode()))
+	if err != nil {
 		return fmt.Errorf("couldn't close session, %w", err)
 	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("couldn't close session, status code %d", resp.StatusCode)
+	}
 
 	return nil
 }
@@ -59,29 +89,58 @@ func Auth(username, password string) (*Session, error) {
 	return AuthWithAddress(Address, username, password)
 }
 
+// AuthContext is the same as Auth, but accepts context.
+func AuthContext(ctx context.Context, username, password string) (*Session, error) {
+	return AuthWithAddressContext(ctx, Address, username, password)
+}
+
 func AuthWithAddress(address, username, password string) (*Session, error) {
-	if err := validateAuthInput(address, username, password); err != nil {
+	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(30)*time.Second)
+	defer cancel()
+
+	return AuthWithAddressContext(rctx, address, username, password)
+}
+
+// AuthWithAddressContext is the same as AuthWithAddress, but accepts context.
+func AuthWithAddressContext(ctx context.Context, address, username, password string) (*Session, error) {
+	return AuthWithClient(ctx, request.DefaultClient, address, username, password)
+}
+
+// AuthWithClient will authenticate to the device at address, sending all
+// requests through the provided client.
+// If username is empty, the user last logged in on the device is selected,
+// like the web interface does on devices without explicit user accounts.
+func AuthWithClient(ctx context.Context, c *request.Client, address, username, password string) (*Session, error) {
+	if err := validateAuthInput(address, password); err != nil {
 		return nil, err
 	}
 
-	challenge, err := getChallengeString(address)
+	session, err := getChallenge(ctx, c, address)
 	if err != nil {
 		return nil, err
 	}
 
-	answer, err := solveChallenge(challenge, password)
+	// The device won't accept any answer during the cooldown
+	if session.BlockTime > 0 {
+		return nil, &BlockTimeError{
+			Duration: session.BlockTime,
+			Message:  "Login blocked. Temporary cooldown for new requests is active",
+		}
+	}
+
+	if username == "" {
+		username = session.lastUser()
+	}
+
+	answer, err := solveChallenge(session.Challenge, password)
 	if err != nil {
 		return nil, err
 	}
 
-	return authenticate(address, answer, username)
+	return authenticate(ctx, c, address, answer, username)
 }
 
-func validateAuthInput(address, username, password string) error {
-	if username == "" {
-		return errors.New("please provide username for authentication")
-	}
-
+func validateAuthInput(address, password string) error {
 	if password == "" {
 		return errors.New("please provide password for authentication")
 	}
@@ -109,7 +168,7 @@ type sessionInfo struct {
 	SID       string
 	Challenge string
 	BlockTime int
-	Rights    string `xml:"Rights,omitempty"`
+	Rights    Rights `xml:"Rights,omitempty"`
 	Users     []user `xml:"Users>User"`
 }
 
@@ -118,30 +177,54 @@ type user struct {
 	Last  int    `xml:"last,attr"`
 }
 
-func getChallengeString(address string) (string, error) {
-	fullAddress := fmt.Sprintf("%s/%s", address, loginPath)
+// lastUser returns the name of the user marked as last logged in, if any.
+func (s *sessionInfo) lastUser() string {
+	for _, u := range s.Users {
+		if u.Last == 1 {
+			return u.Value
+		}
+	}
+	return ""
+}
 
-	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(30)*time.Second)
-	defer cancel()
+func getChallenge(ctx context.Context, c *request.Client, address string) (*sessionInfo, error) {
+	fullAddress, err := request.JoinURL(address, loginPath)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := getSessionInfo(ctx, c, fullAddress)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get the challenge, %w", err)
+	}
+
+	return session, nil
+}
 
-	resp, err := request.GenericGetRequestWithContext(rctx, fullAddress)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("couldn't get the challenge, %w", err)
+// getSessionInfo fetches and parses the SessionInfo document from fullAddress.
+func getSessionInfo(ctx context.Context, c *request.Client, fullAddress string) (*sessionInfo, error) {
+	resp, err := c.GetWithContext(ctx, fullAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
 
 	if !request.ValidateHeader(request.HeaderXML, resp.Header) {
-		return "", ErrInvalidHeaderContentType
+		return nil, ErrInvalidHeaderContentType
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	resp.Body.Close()
 
 	var session *sessionInfo
 	err = xml.Unmarshal(data, &session)
-	return session.Challenge, err
+	return session, err
 }
 
 func solveChallenge(challenge, password string) (string, error) {
@@ -201,26 +284,30 @@ func calculateMD5Response(challenge, password string) string {
 	return fmt.Sprintf("%s-%x", challenge, md5.Sum(b))
 }
 
-func authenticate(address, challenge, username string) (*Session, error) {
-	fullAddress := fmt.Sprintf("%s/%s", address, loginPath)
+func authenticate(ctx context.Context, c *request.Client, address, challenge, username string) (*Session, error) {
+	fullAddress, err := request.JoinURL(address, loginPath)
+	if err != nil {
+		return nil, err
+	}
 
 	p := url.Values{}
 	p.Set("username", username)
 	p.Set("response", challenge)
 
-	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(30)*time.Second)
-	defer cancel()
-
-	resp, err := request.GenericPostRequestWithContext(rctx, fullAddress, strings.NewReader(p.Encode()))
-	if err != nil || resp.StatusCode != http.StatusOK {
+	resp, err := c.PostWithContext(ctx, fullAddress, strings.NewReader(p.Encode()))
+	if err != nil {
 		return nil, fmt.Errorf("something went wrong, %w", err)
 	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("something went wrong, unexpected status code %d", resp.StatusCode)
+	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	resp.Body.Close()
 
 	var session *sessionInfo
 	err = xml.Unmarshal(data, &session)
@@ -228,7 +315,7 @@ func authenticate(address, challenge, username string) (*Session, error) {
 		return nil, err
 	}
 
-	if session.SID == "" || session.SID == "0000000000000000" {
+	if session.SID == "" || session.SID == emptySID {
 		if session.BlockTime > 0 {
 			return nil, &BlockTimeError{
 				Duration: int(session.BlockTime),
@@ -238,6 +325,8 @@ func authenticate(address, challenge, username string) (*Session, error) {
 		return nil, ErrSessionInvalid
 	}
 
-	sid := Session(session.SID)
-	return &sid, nil
+	return &Session{
+		ID:     session.SID,
+		Rights: session.Rights,
+	}, nil
 }
@@ -1,6 +1,11 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/matryer/is"
@@ -34,9 +39,77 @@ func Test_solveChallenge(t *testing.T) {
 
 func Test_CloseWithAddress(t *testing.T) {
 	is := is.New(t)
-	s := Session("2c21f7f4f060848e")
+	s := Session{ID: "2c21f7f4f060848e"}
 	g := &s
 	err := g.CloseWithAddress("http://127.0.0.1:47862")
 	is.True(err != nil)
 	is.Equal(err.Error(), "couldn't close session, Post \"http://127.0.0.1:47862/login_sid.lua?version=2\": dial tcp 127.0.0.1:47862: connect: connection refused")
 }
+
+func Test_AuthWithAddressContext(t *testing.T) {
+	is := is.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := AuthWithAddressContext(ctx, "http://127.0.0.1:47862", "user", "password")
+	is.True(errors.Is(err, context.Canceled))
+}
+
+func Test_ValidateWithAddress(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sid := emptySID
+		if r.URL.Query().Get("sid") == "2c21f7f4f060848e" {
+			sid = "2c21f7f4f060848e"
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, "<SessionInfo><SID>%s</SID><Challenge>1234567z</Challenge><BlockTime>0</BlockTime></SessionInfo>", sid)
+	}))
+	defer ts.Close()
+
+	t.Run("valid session", func(t *testing.T) {
+		s := Session{ID: "2c21f7f4f060848e"}
+		is.NoErr(s.ValidateWithAddress(context.Background(), ts.URL))
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		s := Session{ID: "ffffffffffffffff"}
+		err := s.ValidateWithAddress(context.Background(), ts.URL)
+		is.True(errors.Is(err, ErrSessionExpired))
+	})
+}
+
+func Test_ListUsersAndLastUserLogin(t *testing.T) {
+	is := is.New(t)
+
+	var loginUser string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sid := emptySID
+		if r.Method == http.MethodPost {
+			loginUser = r.FormValue("username")
+			sid = "2c21f7f4f060848e"
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<SessionInfo><SID>%s</SID><Challenge>1234567z</Challenge><BlockTime>0</BlockTime>
+			<Users><User>admin</User><User last="1">fritz1234</User></Users></SessionInfo>`, sid)
+	}))
+	defer ts.Close()
+
+	users, err := ListUsers(context.Background(), ts.URL)
+	is.NoErr(err)
+	is.Equal(users, []string{"admin", "fritz1234"})
+
+	t.Run("password only login selects the last user", func(t *testing.T) {
+		s, err := AuthWithAddress(ts.URL, "", "password")
+		is.NoErr(err)
+		is.Equal(s.String(), "2c21f7f4f060848e")
+		is.Equal(loginUser, "fritz1234")
+	})
+
+	t.Run("explicit user", func(t *testing.T) {
+		_, err := AuthWithAddress(ts.URL, "admin", "password")
+		is.NoErr(err)
+		is.Equal(loginUser, "admin")
+	})
+}
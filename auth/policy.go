@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rumenvasilev/go-fritzos/request"
+)
+
+// LoginPolicy makes a login wait out the cooldown (BlockTime) enforced by the
+// device after failed attempts and retry, instead of failing with BlockTimeError.
+type LoginPolicy struct {
+	// MaxRetries is the number of retries after a blocked attempt.
+	MaxRetries int
+	// MaxWait limits a single wait, longer cooldowns fail right away. Zero means no limit.
+	MaxWait time.Duration
+	// AttemptTimeout limits every login attempt, but not the waits between them. Zero means no limit.
+	AttemptTimeout time.Duration
+	// OnWait is called before every wait with its duration and the total wait so far,
+	// e.g. to show a countdown.
+	OnWait func(wait, total time.Duration)
+	// OnAttempt is called after every login attempt with its result.
+	OnAttempt func(err error)
+}
+
+// AuthWithClient is the same as the package level AuthWithClient, but waits and
+// retries according to the policy. Waiting stops when ctx is done.
+func (p *LoginPolicy) AuthWithClient(ctx context.Context, c *request.Client, address, username, password string) (*Session, error) {
+	var total time.Duration
+	for attempt := 0; ; attempt++ {
+		s, err := p.attempt(ctx, c, address, username, password)
+		if p.OnAttempt != nil {
+			p.OnAttempt(err)
+		}
+
+		var bte *BlockTimeError
+		if !errors.As(err, &bte) || attempt >= p.MaxRetries {
+			return s, err
+		}
+
+		wait := time.Duration(bte.Duration) * time.Second
+		if p.MaxWait > 0 && wait > p.MaxWait {
+			return nil, err
+		}
+
+		total += wait
+		if p.OnWait != nil {
+			p.OnWait(wait, total)
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *LoginPolicy) attempt(ctx context.Context, c *request.Client, address, username, password string) (*Session, error) {
+	if p.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.AttemptTimeout)
+		defer cancel()
+	}
+
+	return AuthWithClient(ctx, c, address, username, password)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/rumenvasilev/go-fritzos/request"
+)
+
+// blockingServer enforces blockTime seconds of cooldown for the first blocked challenges.
+func blockingServer(blockTime, blocked int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sid, bt := emptySID, 0
+		switch {
+		case r.Method == http.MethodPost:
+			sid = "2c21f7f4f060848e"
+		case blocked > 0:
+			blocked--
+			bt = blockTime
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, "<SessionInfo><SID>%s</SID><Challenge>1234567z</Challenge><BlockTime>%d</BlockTime></SessionInfo>", sid, bt)
+	}))
+}
+
+func TestLoginPolicy(t *testing.T) {
+	is := is.New(t)
+
+	t.Run("without policy", func(t *testing.T) {
+		ts := blockingServer(1, 1)
+		defer ts.Close()
+
+		_, err := AuthWithAddress(ts.URL, "user", "password")
+		var bte *BlockTimeError
+		is.True(errors.As(err, &bte))
+		is.Equal(bte.Duration, 1)
+	})
+
+	t.Run("waits and retries", func(t *testing.T) {
+		ts := blockingServer(1, 1)
+		defer ts.Close()
+
+		var waits []time.Duration
+		p := &LoginPolicy{
+			MaxRetries: 2,
+			OnWait: func(wait, total time.Duration) {
+				waits = append(waits, wait, total)
+			},
+		}
+		s, err := p.AuthWithClient(context.Background(), request.DefaultClient, ts.URL, "user", "password")
+		is.NoErr(err)
+		is.Equal(s.String(), "2c21f7f4f060848e")
+		is.Equal(waits, []time.Duration{time.Second, time.Second})
+	})
+
+	t.Run("bounded retries", func(t *testing.T) {
+		ts := blockingServer(1, 5)
+		defer ts.Close()
+
+		p := &LoginPolicy{MaxRetries: 1}
+		_, err := p.AuthWithClient(context.Background(), request.DefaultClient, ts.URL, "user", "password")
+		var bte *BlockTimeError
+		is.True(errors.As(err, &bte))
+	})
+
+	t.Run("wait longer than allowed", func(t *testing.T) {
+		ts := blockingServer(60, 1)
+		defer ts.Close()
+
+		p := &LoginPolicy{MaxRetries: 1, MaxWait: time.Second}
+		_, err := p.AuthWithClient(context.Background(), request.DefaultClient, ts.URL, "user", "password")
+		var bte *BlockTimeError
+		is.True(errors.As(err, &bte))
+		is.Equal(bte.Duration, 60)
+	})
+
+	t.Run("canceled wait", func(t *testing.T) {
+		ts := blockingServer(60, 1)
+		defer ts.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		p := &LoginPolicy{MaxRetries: 1}
+		_, err := p.AuthWithClient(ctx, request.DefaultClient, ts.URL, "user", "password")
+		is.True(errors.Is(err, context.DeadlineExceeded))
+	})
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// Access is the level of access granted by a right.
+type Access int
+
+const (
+	AccessNone  Access = 0
+	AccessRead  Access = 1
+	AccessWrite Access = 2
+)
+
+func (a Access) CanRead() bool {
+	return a >= AccessRead
+}
+
+func (a Access) CanWrite() bool {
+	return a >= AccessWrite
+}
+
+func (a Access) String() string {
+	switch a {
+	case AccessNone:
+		return "none"
+	case AccessRead:
+		return "read"
+	case AccessWrite:
+		return "write"
+	}
+	return strconv.Itoa(int(a))
+}
+
+// Rights holds the access levels of the authenticated user, per area of the device.
+//
+// <Rights>
+//
+//	<Name>NAS</Name>
+//	<Access>2</Access>
+//	<Name>App</Name>
+//	<Access>2</Access>
+//	...
+//
+// </Rights>
+type Rights struct {
+	NAS      Access
+	App      Access
+	HomeAuto Access
+	BoxAdmin Access
+	Phone    Access
+	Dial     Access
+}
+
+// UnmarshalXML decodes the alternating Name and Access elements of SessionInfo.Rights.
+func (r *Rights) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var name string
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var v string
+			if err := d.DecodeElement(&v, &t); err != nil {
+				return err
+			}
+
+			switch t.Name.Local {
+			case "Name":
+				name = strings.TrimSpace(v)
+			case "Access":
+				level, err := strconv.Atoi(strings.TrimSpace(v))
+				if err != nil {
+					return err
+				}
+				r.set(name, Access(level))
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (r *Rights) set(name string, a Access) {
+	switch name {
+	case "NAS":
+		r.NAS = a
+	case "App":
+		r.App = a
+	case "HomeAuto":
+		r.HomeAuto = a
+	case "BoxAdmin":
+		r.BoxAdmin = a
+	case "Phone":
+		r.Phone = a
+	case "Dial":
+		r.Dial = a
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+var sampleSessionInfo = `<?xml version="1.0" encoding="utf-8"?>
+<SessionInfo>
+	<SID>2c21f7f4f060848e</SID>
+	<Challenge>2$60000$492c53ea76b6789a7c40301272276aca$6000$16a80a6246d0ec41a816cc9e03e0e01f</Challenge>
+	<BlockTime>0</BlockTime>
+	<Rights>
+		<Name>Dial</Name>
+		<Access>2</Access>
+		<Name>App</Name>
+		<Access>2</Access>
+		<Name>HomeAuto</Name>
+		<Access>2</Access>
+		<Name>BoxAdmin</Name>
+		<Access>2</Access>
+		<Name>Phone</Name>
+		<Access>2</Access>
+		<Name>NAS</Name>
+		<Access>1</Access>
+	</Rights>
+	<Users>
+		<User last="1">some</User>
+	</Users>
+</SessionInfo>`
+
+func TestRights(t *testing.T) {
+	is := is.New(t)
+
+	var session *sessionInfo
+	err := xml.Unmarshal([]byte(sampleSessionInfo), &session)
+	is.NoErr(err)
+
+	is.Equal(session.Rights, Rights{
+		NAS:      AccessRead,
+		App:      AccessWrite,
+		HomeAuto: AccessWrite,
+		BoxAdmin: AccessWrite,
+		Phone:    AccessWrite,
+		Dial:     AccessWrite,
+	})
+	is.True(session.Rights.NAS.CanRead())
+	is.True(!session.Rights.NAS.CanWrite())
+	is.Equal(session.Users, []user{{Value: "some", Last: 1}})
+
+	t.Run("no rights", func(t *testing.T) {
+		var session *sessionInfo
+		err := xml.Unmarshal([]byte("<SessionInfo><SID>0000000000000000</SID><Rights></Rights></SessionInfo>"), &session)
+		is.NoErr(err)
+		is.Equal(session.Rights, Rights{})
+		is.True(!session.Rights.NAS.CanRead())
+	})
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/rumenvasilev/go-fritzos/request"
+)
+
+// Validate checks whether the session is still alive on the default address.
+// It returns ErrSessionExpired, if the device doesn't accept the session anymore.
+func (s *Session) Validate(ctx context.Context) error {
+	return s.ValidateWithAddress(ctx, Address)
+}
+
+// ValidateWithAddress is the same as Validate, but checks the device at address.
+func (s *Session) ValidateWithAddress(ctx context.Context, address string) error {
+	return s.ValidateWithClient(ctx, request.DefaultClient, address)
+}
+
+// ValidateWithClient checks whether the session is still alive on the device
+// at address, sending the request through the provided client.
+// A successful validation also resets the inactivity timer of the session.
+func (s *Session) ValidateWithClient(ctx context.Context, c *request.Client, address string) error {
+	fullAddress, err := request.JoinURL(address, loginPath+"&sid="+url.QueryEscape(s.String()))
+	if err != nil {
+		return err
+	}
+
+	session, err := getSessionInfo(ctx, c, fullAddress)
+	if err != nil {
+		return fmt.Errorf("couldn't validate session, %w", err)
+	}
+
+	if session.SID == "" || session.SID == emptySID {
+		return ErrSessionExpired
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SessionStore persists sessions, keyed by the address of the device and the user name,
+// so they can be reused across processes.
+type SessionStore interface {
+	// Load returns the stored session or nil, if there is none.
+	Load(address, username string) (*Session, error)
+	Save(address, username string, s *Session) error
+	Delete(address, username string) error
+}
+
+// FileStore is a SessionStore keeping all sessions in a single JSON file,
+// which only its owner can read and write (permissions 0600).
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore creates a store persisting sessions at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// DefaultFileStorePath returns the location of the session file in the user's cache directory.
+func DefaultFileStorePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "go-fritzos", "sessions.json"), nil
+}
+
+func (f *FileStore) Load(address, username string) (*Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := sessions[storeKey(address, username)]
+	if !ok {
+		return nil, nil
+	}
+	return s, nil
+}
+
+func (f *FileStore) Save(address, username string, s *Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	sessions[storeKey(address, username)] = s
+	return f.write(sessions)
+}
+
+func (f *FileStore) Delete(address, username string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sessions, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	key := storeKey(address, username)
+	if _, ok := sessions[key]; !ok {
+		return nil
+	}
+
+	delete(sessions, key)
+	return f.write(sessions)
+}
+
+func (f *FileStore) read() (map[string]*Session, error) {
+	sessions := make(map[string]*Session)
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sessions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &sessions)
+	return sessions, err
+}
+
+// write replaces the session file atomically, so concurrent readers never see partial content.
+func (f *FileStore) write(sessions map[string]*Session) error {
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file with permissions 0600
+	tmp, err := os.CreateTemp(dir, ".sessions-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func storeKey(address, username string) string {
+	return address + "|" + username
+}
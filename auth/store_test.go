@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFileStore(t *testing.T) {
+	is := is.New(t)
+
+	path := filepath.Join(t.TempDir(), "cache", "sessions.json")
+	store := NewFileStore(path)
+
+	s, err := store.Load("http://fritz.box", "user")
+	is.NoErr(err)
+	is.Equal(s, nil) // empty store
+
+	want := &Session{ID: "2c21f7f4f060848e", Rights: Rights{NAS: AccessWrite}}
+	is.NoErr(store.Save("http://fritz.box", "user", want))
+	is.NoErr(store.Save("http://192.168.178.1", "user", &Session{ID: "ffffffffffffffff"}))
+
+	fi, err := os.Stat(path)
+	is.NoErr(err)
+	is.Equal(fi.Mode().Perm(), os.FileMode(0600))
+
+	// a new store reads the persisted sessions
+	s, err = NewFileStore(path).Load("http://fritz.box", "user")
+	is.NoErr(err)
+	is.Equal(s, want)
+
+	s, err = store.Load("http://fritz.box", "other")
+	is.NoErr(err)
+	is.Equal(s, nil)
+
+	is.NoErr(store.Delete("http://fritz.box", "user"))
+	s, err = store.Load("http://fritz.box", "user")
+	is.NoErr(err)
+	is.Equal(s, nil)
+
+	s, err = store.Load("http://192.168.178.1", "user")
+	is.NoErr(err)
+	is.Equal(s.String(), "ffffffffffffffff")
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/rumenvasilev/go-fritzos/request"
+)
+
+// ListUsers returns the user names, which the device at address offers on its login page.
+func ListUsers(ctx context.Context, address string) ([]string, error) {
+	return ListUsersWithClient(ctx, request.DefaultClient, address)
+}
+
+// ListUsersWithClient is the same as ListUsers, but sends the request through the provided client.
+func ListUsersWithClient(ctx context.Context, c *request.Client, address string) ([]string, error) {
+	session, err := getChallenge(ctx, c, address)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]string, 0, len(session.Users))
+	for _, u := range session.Users {
+		users = append(users, u.Value)
+	}
+
+	return users, nil
+}
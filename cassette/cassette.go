@@ -0,0 +1,107 @@
+// Package cassette records the HTTP traffic between the client and a device into
+// cassette files and replays it, e.g. to build a regression corpus of the
+// responses of different firmware versions.
+//
+// Recording:
+//
+//	rec := cassette.NewRecorder(http.DefaultTransport)
+//	c := fritzos.New().WithHTTPClient(&http.Client{Transport: rec})
+//	// ... log in and run operations
+//	err := rec.Cassette().Save("testdata/7.57.json")
+//
+// Replaying:
+//
+//	cas, err := cassette.Load("testdata/7.57.json")
+//	c := fritzos.New().WithHTTPClient(&http.Client{Transport: cassette.NewReplayer(cas)})
+//
+// Session ids and password responses are scrubbed from recordings.
+package cassette
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// Cassette holds recorded interactions in the order they happened.
+type Cassette struct {
+	// Firmware is free-form metadata, e.g. the FRITZ!OS version the recording was made with.
+	Firmware     string         `json:"firmware,omitempty"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Body is stored as text, or base64 encoded if it isn't valid UTF-8 (e.g. downloaded files).
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(map[string]string{"text": string(b)})
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Text   string `json:"text"`
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if v.Base64 == "" {
+		*b = Body(v.Text)
+		return nil
+	}
+
+	d, err := base64.StdEncoding.DecodeString(v.Base64)
+	*b = d
+	return err
+}
+
+// Load reads the cassette at path.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	err = json.Unmarshal(data, &c)
+	return &c, err
+}
+
+// Save writes the cassette to path, creating its directory if needed.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,118 @@
+package cassette_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/cassette"
+	"github.com/rumenvasilev/go-fritzos/fritztest"
+	"github.com/rumenvasilev/go-fritzos/nas"
+)
+
+// session runs the operations, which are recorded and replayed.
+func session(is *is.I, c *fritzos.Client) (*nas.BrowseResponse, []byte) {
+	is.NoErr(c.Login("fritz1234", "secret"))
+	n := nas.NewWithClient(c)
+
+	_, err := n.PutFile("/Dokumente/b.bin", bytes.NewReader([]byte{0xff, 0x00, 0xfe}))
+	is.NoErr(err)
+
+	res, err := n.ListDirectory("/Dokumente")
+	is.NoErr(err)
+
+	rc, err := n.GetFile("/Dokumente/b.bin")
+	is.NoErr(err)
+	data, err := io.ReadAll(rc)
+	is.NoErr(err)
+	rc.Close()
+
+	is.NoErr(c.Close())
+	return res, data
+}
+
+func TestRecordReplay(t *testing.T) {
+	is := is.New(t)
+
+	box := fritztest.NewServer(t).WithUser("fritz1234", "secret")
+	box.Mkdir("/Dokumente")
+
+	rec := cassette.NewRecorder(box.Client().Transport)
+	c := box.NewClient().WithHTTPClient(&http.Client{Transport: rec})
+	recorded, recordedData := session(is, c)
+
+	cas := rec.Cassette()
+	cas.Firmware = "7.57"
+	path := filepath.Join(t.TempDir(), "7.57.json")
+	is.NoErr(cas.Save(path))
+
+	raw, err := os.ReadFile(path)
+	is.NoErr(err)
+	is.True(strings.Contains(string(raw), cassette.Redacted)) // password response
+
+	loaded, err := cassette.Load(path)
+	is.NoErr(err)
+	is.Equal(loaded.Firmware, "7.57")
+
+	// Replay without the device
+	box.Close()
+	replayer := cassette.NewReplayer(loaded)
+	c = fritzos.New().WithAddress("http://fritz.box").WithHTTPClient(&http.Client{Transport: replayer})
+	replayed, replayedData := session(is, c)
+
+	is.Equal(replayed, recorded)
+	is.Equal(replayedData, recordedData)
+	is.Equal(replayer.Unused(), 0)
+}
+
+func TestRecorderScrubsSessionIDs(t *testing.T) {
+	is := is.New(t)
+
+	box := fritztest.NewServer(t).WithUser("fritz1234", "secret")
+	rec := cassette.NewRecorder(box.Client().Transport)
+	c := box.NewClient().WithHTTPClient(&http.Client{Transport: rec})
+
+	is.NoErr(c.Login("fritz1234", "secret"))
+	realSID := c.Session().ID
+	_, err := nas.NewWithClient(c).ListDirectory("/")
+	is.NoErr(err)
+
+	var buf bytes.Buffer
+	for _, i := range rec.Cassette().Interactions {
+		buf.WriteString(i.Request.URL)
+		buf.Write(i.Request.Body)
+		buf.Write(i.Response.Body)
+	}
+	is.True(!strings.Contains(buf.String(), realSID))
+}
+
+func TestRecorderScrubsExistingSession(t *testing.T) {
+	is := is.New(t)
+
+	box := fritztest.NewServer(t).WithUser("fritz1234", "secret")
+	login := box.NewClient()
+	is.NoErr(login.Login("fritz1234", "secret"))
+	sess := login.Session()
+
+	// the recording starts with a session, which the recorder hasn't seen being created
+	rec := cassette.NewRecorder(box.Client().Transport)
+	c := box.NewClient().WithHTTPClient(&http.Client{Transport: rec}).WithSession(sess)
+
+	_, err := nas.NewWithClient(c).PutFile("/Dokumente/b.bin", bytes.NewReader([]byte("data")))
+	is.NoErr(err)
+
+	interactions := rec.Cassette().Interactions
+	is.Equal(len(interactions), 1)
+	for _, i := range interactions {
+		is.True(!strings.Contains(i.Request.URL, sess.ID))
+		is.True(!bytes.Contains(i.Request.Body, []byte(sess.ID)))
+		is.True(!bytes.Contains(i.Response.Body, []byte(sess.ID)))
+		is.True(bytes.Contains(i.Response.Body, []byte(cassette.ScrubbedSID)))
+	}
+}
@@ -0,0 +1,195 @@
+package cassette
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// ScrubbedSID replaces the session ids in recordings. It is a valid session id,
+	// so replayed logins succeed.
+	ScrubbedSID = "5c0b5c0b5c0b5c0b"
+	// Redacted replaces password responses in recordings.
+	Redacted = "REDACTED"
+
+	emptySID = "0000000000000000"
+)
+
+var (
+	sidElement = regexp.MustCompile(`<SID>([0-9a-fA-F]{16})</SID>`)
+	// sidField matches the session id in JSON, e.g. of nasupload_notimeout responses
+	sidField = regexp.MustCompile(`(?i)"sid"\s*:\s*"([0-9a-f]{16})"`)
+)
+
+// Recorder is a http.RoundTripper, which sends requests through Next and records
+// them with their responses. Request and response bodies are buffered in memory.
+type Recorder struct {
+	Next http.RoundTripper
+	// Scrub is called with every interaction before it is recorded, e.g. to remove
+	// file names or contents. Session ids and password responses are always scrubbed.
+	Scrub func(*Interaction)
+
+	mu       sync.Mutex
+	cassette Cassette
+	sids     map[string]bool
+}
+
+// NewRecorder creates a recorder sending requests through next.
+func NewRecorder(next http.RoundTripper) *Recorder {
+	return &Recorder{Next: next}
+}
+
+// Cassette returns the interactions recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.cassette
+	c.Interactions = append([]*Interaction(nil), c.Interactions...)
+	return &c
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	next := r.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.record(&Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+			Body:   reqBody,
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: resp.Header.Clone(),
+			Body:   respBody,
+		},
+	})
+
+	return resp, nil
+}
+
+func (r *Recorder) record(i *Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sids == nil {
+		r.sids = make(map[string]bool)
+	}
+
+	// Learn the session ids, so they are scrubbed wherever they appear
+	if u, err := url.Parse(i.Request.URL); err == nil {
+		r.learn(u.Query().Get("sid"))
+	}
+	if form, err := url.ParseQuery(string(i.Request.Body)); err == nil {
+		r.learn(form.Get("sid"))
+		r.learn(form.Get("logout"))
+	}
+	for _, sid := range multipartSIDs(i.Request) {
+		r.learn(sid)
+	}
+	for _, re := range []*regexp.Regexp{sidElement, sidField} {
+		for _, m := range re.FindAllStringSubmatch(string(i.Response.Body), -1) {
+			r.learn(m[1])
+		}
+	}
+
+	r.scrub(i)
+	if r.Scrub != nil {
+		r.Scrub(i)
+	}
+
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+}
+
+// multipartSIDs returns the sid fields of a multipart form, e.g. of an upload.
+func multipartSIDs(req Request) []string {
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil
+	}
+
+	var sids []string
+	mr := multipart.NewReader(bytes.NewReader(req.Body), params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return sids
+		}
+		if part.FormName() == "sid" {
+			v, _ := io.ReadAll(io.LimitReader(part, 64))
+			sids = append(sids, string(v))
+		}
+	}
+}
+
+func (r *Recorder) learn(sid string) {
+	if len(sid) == len(emptySID) && sid != emptySID {
+		r.sids[sid] = true
+	}
+}
+
+// scrub replaces the known session ids and password responses in i.
+func (r *Recorder) scrub(i *Interaction) {
+	replace := func(s string) string {
+		for sid := range r.sids {
+			s = strings.ReplaceAll(s, sid, ScrubbedSID)
+		}
+		return s
+	}
+
+	i.Request.URL = replace(i.Request.URL)
+	i.Request.Body = Body(replace(string(i.Request.Body)))
+	i.Response.Body = Body(replace(string(i.Response.Body)))
+
+	if form, err := url.ParseQuery(string(i.Request.Body)); err == nil && form.Has("response") {
+		form.Set("response", Redacted)
+		i.Request.Body = Body(form.Encode())
+	}
+
+	for _, h := range []http.Header{i.Request.Header, i.Response.Header} {
+		for k, vs := range h {
+			for j := range vs {
+				vs[j] = replace(vs[j])
+			}
+			h[k] = vs
+		}
+	}
+	i.Request.Header.Del("Authorization")
+	i.Request.Header.Del("Cookie")
+	i.Response.Header.Del("Set-Cookie")
+}
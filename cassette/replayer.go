@@ -0,0 +1,112 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// ignoredParams differ between recording and replay, so they are not matched.
+var ignoredParams = []string{"sid", "response", "logout"}
+
+// Replayer is a http.RoundTripper serving the responses of a cassette. Every
+// request is answered by the first unused interaction with the same method, path
+// and parameters (ignoring session ids and password responses).
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayer creates a replayer serving c.
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{
+		cassette: c,
+		used:     make([]bool, len(c.Interactions)),
+	}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	want := key(req.Method, req.URL.String(), req.Header.Get("Content-Type"), body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n, i := range r.cassette.Interactions {
+		if r.used[n] {
+			continue
+		}
+
+		got := key(i.Request.Method, i.Request.URL, i.Request.Header.Get("Content-Type"), i.Request.Body)
+		if got != want {
+			continue
+		}
+
+		r.used[n] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Response.Status, http.StatusText(i.Response.Status)),
+			StatusCode:    i.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        i.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(i.Response.Body)),
+			ContentLength: int64(len(i.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette has no interaction for %s %s", req.Method, req.URL.Path)
+}
+
+// Unused returns the number of interactions, which weren't replayed yet.
+func (r *Replayer) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// key identifies a request by method, path and parameters. Form bodies are
+// matched by their parameters, other bodies (e.g. multipart uploads) are ignored.
+func key(method, rawURL, contentType string, body []byte) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL
+	}
+
+	params := u.Query()
+	if mt, _, _ := mime.ParseMediaType(contentType); mt == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for k, vs := range form {
+				params[k] = append(params[k], vs...)
+			}
+		}
+	}
+
+	for _, p := range ignoredParams {
+		params.Del(p)
+	}
+
+	return method + " " + u.Path + "?" + params.Encode()
+}
@@ -0,0 +1,453 @@
+// Package fritzos is the entry point of the SDK. It provides a Client, which owns
+// the transport, address, timeouts and session used by all subsystems (e.g. nas).
+package fritzos
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/rumenvasilev/go-fritzos/auth"
+	"github.com/rumenvasilev/go-fritzos/request"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultTimeout       = 30 * time.Second
+	DefaultModifyTimeout = 60 * time.Second
+	DefaultUserAgent     = "go-fritzos"
+)
+
+// Timeouts holds the time limits applied to calls of the client and its subsystems.
+type Timeouts struct {
+	// Request limits regular requests, like authentication, listing and file transfers.
+	Request time.Duration
+	// Modify limits requests changing the storage, like create, rename, move or delete.
+	Modify time.Duration
+}
+
+// Client is safe for concurrent use and should be reused, so connections
+// to the device are pooled.
+type Client struct {
+	mu sync.RWMutex
+	// authMu serializes re-authentication, so concurrent callers
+	// noticing an expired session trigger only one login.
+	authMu sync.Mutex
+
+	address    string
+	timeouts   Timeouts
+	httpClient *http.Client
+	tls        tlsOptions
+	proxy      *url.URL
+	dial       func(ctx context.Context, network, addr string) (net.Conn, error)
+	logger     *slog.Logger
+	retry      *RetryPolicy
+	middleware []Middleware
+	tracer     trace.Tracer
+	observer   Observer
+	// limiter is kept across rebuilds of hc, so the budget is never reset
+	limiter *limiter
+	// hc is built from httpClient and the transport options on first use
+	hc        *http.Client
+	userAgent string
+	session   *auth.Session
+	username  string
+	password  string
+	policy    *auth.LoginPolicy
+	store     auth.SessionStore
+
+	stopKeepAlive func()
+}
+
+// New creates a client for the default address (auth.Address).
+func New() *Client {
+	return &Client{
+		address: auth.Address,
+		timeouts: Timeouts{
+			Request: DefaultTimeout,
+			Modify:  DefaultModifyTimeout,
+		},
+		httpClient: &http.Client{},
+		userAgent:  DefaultUserAgent,
+	}
+}
+
+// WithAddress sets the base address of the target device, e.g. http://192.168.178.1
+// Ports and path prefixes are supported, e.g. https://xyz.myfritz.net:4711 for
+// remote access through MyFRITZ.
+func (c *Client) WithAddress(addr string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.address = addr
+	c.hc = nil
+	return c
+}
+
+// WithHTTPClient replaces the underlying http.Client, e.g. to plug in a custom transport.
+func (c *Client) WithHTTPClient(hc *http.Client) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.httpClient = hc
+	c.hc = nil
+	return c
+}
+
+// WithProxy sends all requests through the proxy at proxyURL, e.g.
+// http://proxy:3128 or socks5://localhost:1080 for an SSH tunnel (ssh -D 1080).
+// Without it, the proxy is taken from the environment (HTTPS_PROXY, HTTP_PROXY, NO_PROXY).
+func (c *Client) WithProxy(proxyURL *url.URL) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.proxy = proxyURL
+	c.hc = nil
+	return c
+}
+
+// WithDialContext sets the function used to open connections to the device
+// (or the proxy), e.g. to tunnel them through an existing SSH connection.
+func (c *Client) WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dial = dial
+	c.hc = nil
+	return c
+}
+
+// WithLogger makes the client log every request to the device at debug level,
+// with the session id redacted. The client is silent by default.
+func (c *Client) WithLogger(l *slog.Logger) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = l
+	c.hc = nil
+	return c
+}
+
+// WithTimeouts replaces the default timeouts. Zero values keep the current setting.
+func (c *Client) WithTimeouts(t Timeouts) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.Request > 0 {
+		c.timeouts.Request = t.Request
+	}
+	if t.Modify > 0 {
+		c.timeouts.Modify = t.Modify
+	}
+	return c
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func (c *Client) WithUserAgent(ua string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.userAgent = ua
+	return c
+}
+
+// WithSession sets an already authenticated session, instead of calling Login.
+func (c *Client) WithSession(s *auth.Session) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session = s
+	return c
+}
+
+// WithLoginPolicy makes logins (and re-authentication) wait out the cooldown enforced
+// by the device and retry, according to p. Unless set by p, every attempt is limited
+// by the request timeout.
+func (c *Client) WithLoginPolicy(p *auth.LoginPolicy) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policy = p
+	return c
+}
+
+// WithSessionStore makes Login reuse a still valid session from store, instead of
+// authenticating again, and persist new sessions to it.
+// Close keeps stored sessions alive on the device, use Logout to end them.
+func (c *Client) WithSessionStore(store auth.SessionStore) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = store
+	return c
+}
+
+// Address returns the base address of the target device.
+func (c *Client) Address() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.address
+}
+
+// Username returns the name of the authenticated user, empty if the user last
+// logged in was selected.
+func (c *Client) Username() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.username
+}
+
+// Timeouts returns the configured timeouts.
+func (c *Client) Timeouts() Timeouts {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.timeouts
+}
+
+// Session returns the current session or nil, if the client is not authenticated.
+func (c *Client) Session() *auth.Session {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.session
+}
+
+// Requester returns the request client, which all subsystems use to talk to the device.
+func (c *Client) Requester() *request.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hc == nil {
+		c.hc = c.buildHTTPClient()
+	}
+
+	return &request.Client{
+		HTTPClient: c.hc,
+		UserAgent:  c.userAgent,
+	}
+}
+
+// Login authenticates to the target device and stores the session in the client.
+// Credentials are kept in memory, so the client can re-authenticate once the session expires.
+func (c *Client) Login(username, password string) error {
+	c.mu.RLock()
+	policy := c.policy
+	c.mu.RUnlock()
+
+	// With a login policy, the attempts are time limited, but not the waits in between
+	if policy != nil {
+		return c.LoginContext(context.Background(), username, password)
+	}
+
+	rctx, cancel := context.WithTimeout(context.Background(), c.Timeouts().Request)
+	defer cancel()
+
+	return c.LoginContext(rctx, username, password)
+}
+
+// LoginContext is the same as Login, but accepts context.
+func (c *Client) LoginContext(ctx context.Context, username, password string) (err error) {
+	ctx, span := c.Tracer().Start(ctx, "Login", trace.WithAttributes(attribute.String("fritzos.username", username)))
+	defer func() { EndSpan(span, err) }()
+
+	sess := c.storedSession(ctx, username)
+	span.SetAttributes(attribute.Bool("fritzos.session.reused", sess != nil))
+	if sess == nil {
+		sess, err = c.authenticate(ctx, username, password)
+		if err != nil {
+			return err
+		}
+		c.storeSession(username, sess)
+	}
+
+	c.mu.Lock()
+	c.session = sess
+	c.username = username
+	c.password = password
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Client) authenticate(ctx context.Context, username, password string) (*auth.Session, error) {
+	c.mu.RLock()
+	policy := c.policy
+	c.mu.RUnlock()
+
+	if policy == nil {
+		sess, err := auth.AuthWithClient(ctx, c.Requester(), c.Address(), username, password)
+		c.observeLogin(err)
+		return sess, err
+	}
+
+	p := *policy
+	if p.AttemptTimeout == 0 {
+		p.AttemptTimeout = c.Timeouts().Request
+	}
+	onAttempt := p.OnAttempt
+	p.OnAttempt = func(err error) {
+		c.observeLogin(err)
+		if onAttempt != nil {
+			onAttempt(err)
+		}
+	}
+	return p.AuthWithClient(ctx, c.Requester(), c.Address(), username, password)
+}
+
+func (c *Client) observeLogin(err error) {
+	e := LoginEvent{Err: err}
+
+	var bte *auth.BlockTimeError
+	if errors.As(err, &bte) {
+		e.BlockTime = time.Duration(bte.Duration) * time.Second
+	}
+
+	c.Observer().ObserveLogin(e)
+}
+
+// LoginWithProvider is the same as LoginContext, but the credentials are supplied by p.
+func (c *Client) LoginWithProvider(ctx context.Context, p auth.CredentialProvider) error {
+	creds, err := p.Credentials(ctx, c.Address())
+	if err != nil {
+		return err
+	}
+
+	return c.LoginContext(ctx, creds.Username, creds.Password)
+}
+
+// Resume reuses a still valid session of username from the session store, without
+// authenticating. It returns auth.ErrSessionExpired, if there is none.
+func (c *Client) Resume(ctx context.Context, username string) error {
+	sess := c.storedSession(ctx, username)
+	if sess == nil {
+		return auth.ErrSessionExpired
+	}
+
+	c.mu.Lock()
+	c.session = sess
+	c.username = username
+	c.mu.Unlock()
+	return nil
+}
+
+// storedSession returns a still valid session from the session store, if any.
+// The store is only an optimization, so its failures lead to a new login.
+func (c *Client) storedSession(ctx context.Context, username string) *auth.Session {
+	c.mu.RLock()
+	store, address := c.store, c.address
+	c.mu.RUnlock()
+
+	if store == nil {
+		return nil
+	}
+
+	sess, err := store.Load(address, username)
+	if err != nil || sess == nil {
+		return nil
+	}
+
+	err = sess.ValidateWithClient(ctx, c.Requester(), address)
+	if errors.Is(err, auth.ErrSessionExpired) {
+		_ = store.Delete(address, username)
+	}
+	if err != nil {
+		return nil
+	}
+
+	return sess
+}
+
+func (c *Client) storeSession(username string, sess *auth.Session) {
+	c.mu.RLock()
+	store, address := c.store, c.address
+	c.mu.RUnlock()
+
+	if store != nil {
+		_ = store.Save(address, username, sess)
+	}
+}
+
+// ListUsers returns the user names, which the device offers on its login page.
+func (c *Client) ListUsers(ctx context.Context) (users []string, err error) {
+	ctx, span := c.Tracer().Start(ctx, "ListUsers")
+	defer func() { EndSpan(span, err) }()
+
+	return auth.ListUsersWithClient(ctx, c.Requester(), c.Address())
+}
+
+// Reauthenticate runs a new login with the stored credentials, replacing the
+// session with id staleSID. If the session was already replaced in the meantime
+// (e.g. by a concurrent caller), it returns immediately.
+func (c *Client) Reauthenticate(ctx context.Context, staleSID string) (err error) {
+	ctx, span := c.Tracer().Start(ctx, "Reauthenticate")
+	defer func() { EndSpan(span, err) }()
+
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	c.mu.RLock()
+	sess, username, password := c.session, c.username, c.password
+	c.mu.RUnlock()
+
+	if sess != nil && sess.String() != staleSID {
+		return nil
+	}
+
+	if password == "" {
+		// Without credentials, only a session renewed elsewhere (e.g. by
+		// another process sharing the session store) can be picked up
+		if err := c.Resume(ctx, username); err == nil {
+			return nil
+		}
+		return ErrNoCredentials
+	}
+
+	return c.LoginContext(ctx, username, password)
+}
+
+// Close will logout from the target device, if the client holds a session.
+// With a session store, the session is kept alive for reuse instead.
+func (c *Client) Close() error {
+	rctx, cancel := context.WithTimeout(context.Background(), c.Timeouts().Request)
+	defer cancel()
+
+	return c.CloseContext(rctx)
+}
+
+// CloseContext is the same as Close, but accepts context.
+func (c *Client) CloseContext(ctx context.Context) error {
+	c.stopKeepAliveLoop()
+
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+
+	if store != nil {
+		return nil
+	}
+
+	return c.Logout(ctx)
+}
+
+// Logout ends the session on the target device and removes it from the session store.
+func (c *Client) Logout(ctx context.Context) (err error) {
+	ctx, span := c.Tracer().Start(ctx, "Logout")
+	defer func() { EndSpan(span, err) }()
+
+	c.stopKeepAliveLoop()
+
+	c.mu.RLock()
+	sess, store, address, username := c.session, c.store, c.address, c.username
+	c.mu.RUnlock()
+
+	if sess == nil {
+		return nil
+	}
+
+	if store != nil {
+		_ = store.Delete(address, username)
+	}
+
+	if err := sess.CloseWithClient(ctx, c.Requester(), address); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.session = nil
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,157 @@
+package fritzos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/rumenvasilev/go-fritzos/auth"
+)
+
+const (
+	testChallenge = "2$10000$5A1711$2000$5A1722"
+	testPassword  = "1example!"
+	testResponse  = "5A1722$1798a1672bca7c6463d6b245f82b53703b0f50813401b03e4045a5861e689adb"
+	testSID       = "2c21f7f4f060848e"
+)
+
+func loginHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "fritz-test" {
+			t.Errorf("unexpected user agent %q", r.Header.Get("User-Agent"))
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		sid := "0000000000000000"
+		switch {
+		case r.Method == http.MethodPost && r.FormValue("response") == testResponse:
+			sid = testSID
+		case r.Method == http.MethodPost && r.FormValue("logout") != "":
+			sid = "0000000000000000"
+		case r.URL.Query().Get("sid") == testSID:
+			sid = testSID
+		}
+		fmt.Fprintf(w, "<SessionInfo><SID>%s</SID><Challenge>%s</Challenge><BlockTime>0</BlockTime></SessionInfo>", sid, testChallenge)
+	}
+}
+
+func TestClientLogin(t *testing.T) {
+	is := is.New(t)
+
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		loginHandler(t)(w, r)
+	}))
+	defer ts.Close()
+
+	c := New().WithAddress(ts.URL).WithHTTPClient(ts.Client()).WithUserAgent("fritz-test")
+	is.Equal(c.Session(), nil)
+	is.Equal(c.Timeouts(), Timeouts{Request: DefaultTimeout, Modify: DefaultModifyTimeout})
+
+	err := c.Login("user", testPassword)
+	is.NoErr(err)
+	is.True(c.Session() != nil)
+	is.Equal(c.Session().String(), testSID)
+
+	err = c.Close()
+	is.NoErr(err)
+	is.Equal(c.Session(), nil)
+	is.Equal(requests, 3) // challenge, response, logout
+}
+
+func TestClientWithTimeouts(t *testing.T) {
+	is := is.New(t)
+
+	c := New().WithTimeouts(Timeouts{Modify: 5 * DefaultModifyTimeout})
+	is.Equal(c.Timeouts().Request, DefaultTimeout)
+	is.Equal(c.Timeouts().Modify, 5*DefaultModifyTimeout)
+}
+
+func TestClientKeepAlive(t *testing.T) {
+	is := is.New(t)
+
+	var validations atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sid") != "" {
+			validations.Add(1)
+		}
+		loginHandler(t)(w, r)
+	}))
+	defer ts.Close()
+
+	c := New().WithAddress(ts.URL).WithHTTPClient(ts.Client()).WithUserAgent("fritz-test")
+	is.NoErr(c.Login("user", testPassword))
+
+	c.KeepAlive(5 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	is.NoErr(c.Close())
+
+	n := validations.Load()
+	is.True(n > 0)
+	time.Sleep(20 * time.Millisecond)
+	is.Equal(validations.Load(), n) // stopped on Close
+}
+
+func TestClientKeepAliveInterval(t *testing.T) {
+	is := is.New(t)
+
+	c := New()
+	for _, interval := range []time.Duration{0, -time.Second} {
+		c.KeepAlive(interval) // doesn't panic
+	}
+	is.NoErr(c.Close())
+}
+
+func TestClientSessionStore(t *testing.T) {
+	is := is.New(t)
+
+	var logins, logouts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.FormValue("response") != "" {
+			logins++
+		}
+		if r.Method == http.MethodPost && r.FormValue("logout") != "" {
+			logouts++
+		}
+		loginHandler(t)(w, r)
+	}))
+	defer ts.Close()
+
+	store := auth.NewFileStore(filepath.Join(t.TempDir(), "sessions.json"))
+	newClient := func() *Client {
+		return New().WithAddress(ts.URL).WithHTTPClient(ts.Client()).WithUserAgent("fritz-test").WithSessionStore(store)
+	}
+
+	c := newClient()
+	is.NoErr(c.Login("user", testPassword))
+	is.NoErr(c.Close())
+	is.Equal(logins, 1)
+	is.Equal(logouts, 0) // session is kept for reuse
+
+	c = newClient()
+	is.NoErr(c.Login("user", testPassword))
+	is.Equal(logins, 1) // reused from the store
+	is.Equal(c.Session().String(), testSID)
+
+	is.NoErr(c.Logout(context.Background()))
+	is.Equal(logouts, 1)
+	s, err := store.Load(ts.URL, "user")
+	is.NoErr(err)
+	is.Equal(s, nil)
+
+	t.Run("expired session in store", func(t *testing.T) {
+		is.NoErr(store.Save(ts.URL, "user", &auth.Session{ID: "ffffffffffffffff"}))
+
+		c := newClient()
+		is.NoErr(c.Login("user", testPassword))
+		is.Equal(logins, 2)
+		is.Equal(c.Session().String(), testSID)
+	})
+}
@@ -0,0 +1,26 @@
+package fritzos
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoCredentials = errNoCredentials()
+)
+
+func errNoCredentials() error {
+	return errors.New("cannot re-authenticate, client holds no credentials")
+}
+
+// CertificateMismatchError is returned, when the certificate of the device
+// doesn't match the pinned one.
+type CertificateMismatchError struct {
+	Host   string
+	Pinned string
+	Got    string
+}
+
+func (e *CertificateMismatchError) Error() string {
+	return fmt.Sprintf("certificate of %s has changed, pinned fingerprint %s, got %s", e.Host, e.Pinned, e.Got)
+}
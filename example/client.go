@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/agent"
+	"github.com/rumenvasilev/go-fritzos/auth"
+	"golang.org/x/term"
+)
+
+const (
+	addressEnv         = "FRITZ_ADDRESS"
+	debugEnv           = "FRITZ_DEBUG"
+	agentSocketEnv     = "FRITZ_AGENT_SOCK"
+	credentialsFileEnv = "FRITZ_CREDENTIALS_FILE"
+	passphraseEnv      = "FRITZ_CREDENTIALS_PASSPHRASE"
+)
+
+// newClient creates a client for the default address and authenticates as username.
+// If FRITZ_AGENT_SOCK is set, the session of the running agent is used instead.
+// Otherwise sessions are cached in the user's cache directory and reused by
+// subsequent invocations, as long as they are valid.
+func newClient(username string) (*fritzos.Client, error) {
+	if sock := os.Getenv(agentSocketEnv); sock != "" {
+		c := newBaseClient().WithSessionStore(agent.NewClient(sock))
+		if err := c.Resume(context.Background(), resolveUsername(c.Address(), username)); err != nil {
+			return nil, fmt.Errorf("couldn't get a session from the agent, %w", err)
+		}
+		return c, nil
+	}
+
+	return login(username)
+}
+
+// login authenticates as username.
+// Passwords are never passed as flags, instead they are looked up in this order:
+// FRITZ_PASSWORD environment variable, ~/.netrc, the encrypted credentials file
+// and finally an interactive prompt.
+// When the device enforces a login cooldown, it waits and retries, showing a countdown.
+func login(username string) (*fritzos.Client, error) {
+	c := newLoginClient()
+
+	if path, err := auth.DefaultFileStorePath(); err == nil {
+		c.WithSessionStore(auth.NewFileStore(path))
+	}
+
+	// Sessions are stored under the username of the login, so it must be known
+	// before the credentials are looked up, which may ask for a passphrase
+	if err := c.Resume(context.Background(), resolveUsername(c.Address(), username)); err == nil {
+		return c, nil
+	}
+
+	if err := loginWithProvider(c, username); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newBaseClient creates a client for the address in FRITZ_ADDRESS, e.g. the MyFRITZ
+// address https://xyz.myfritz.net:4711, defaulting to http://fritz.box.
+// Certificates of https addresses are pinned on first use.
+// Proxies are taken from the environment (HTTPS_PROXY, e.g. socks5://localhost:1080).
+// With FRITZ_DEBUG set, all requests are logged to stderr.
+func newBaseClient() *fritzos.Client {
+	c := fritzos.New().WithRetryPolicy(fritzos.DefaultRetryPolicy())
+
+	if os.Getenv(debugEnv) != "" {
+		c.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	}
+
+	address := os.Getenv(addressEnv)
+	if address == "" {
+		return c
+	}
+	c.WithAddress(address)
+
+	if strings.HasPrefix(address, "https://") {
+		if path, err := fritzos.DefaultPinStorePath(); err == nil {
+			c.WithTLSPinning(fritzos.NewFilePinStore(path))
+		}
+	}
+	return c
+}
+
+func newLoginClient() *fritzos.Client {
+	return newBaseClient().WithLoginPolicy(&auth.LoginPolicy{
+		MaxRetries: 3,
+		MaxWait:    5 * time.Minute,
+		OnWait:     countdown,
+	})
+}
+
+func loginWithProvider(c *fritzos.Client, username string) error {
+	if err := c.LoginWithProvider(context.Background(), credentialProvider(username)); err != nil {
+		return err
+	}
+
+	log.Println("Login successful! Session ID", c.Session())
+	return nil
+}
+
+func credentialProvider(username string) auth.CredentialProvider {
+	return &usernameOverride{
+		username: username,
+		provider: auth.ChainProvider{
+			&auth.EnvProvider{},
+			&auth.NetrcProvider{},
+			&auth.EncryptedFileProvider{
+				Path:       credentialsFile(),
+				Passphrase: passphrase,
+			},
+			&auth.PromptProvider{Username: username},
+		},
+	}
+}
+
+// resolveUsername returns the username, which credentialProvider logs in with at address.
+// Only the providers, which don't ask for a passphrase or password, are consulted.
+func resolveUsername(address, username string) string {
+	if username != "" {
+		return username
+	}
+
+	quiet := auth.ChainProvider{&auth.EnvProvider{}, &auth.NetrcProvider{}}
+	if creds, err := quiet.Credentials(context.Background(), address); err == nil {
+		return creds.Username
+	}
+	return os.Getenv(auth.DefaultUsernameEnv)
+}
+
+// usernameOverride replaces the username supplied by provider, if one was passed as flag.
+type usernameOverride struct {
+	username string
+	provider auth.CredentialProvider
+}
+
+func (u *usernameOverride) Credentials(ctx context.Context, address string) (*auth.Credentials, error) {
+	creds, err := u.provider.Credentials(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.username != "" {
+		creds.Username = u.username
+	}
+	return creds, nil
+}
+
+// credentialsFile returns the path of the encrypted credentials file.
+func credentialsFile() string {
+	if p := os.Getenv(credentialsFileEnv); p != "" {
+		return p
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-fritzos", "credentials")
+}
+
+// passphrase reads the passphrase of the credentials file from the environment or the terminal.
+func passphrase() ([]byte, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return []byte(p), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase for the credentials file: ")
+	p, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return p, err
+}
+
+// countdown prints the remaining time of a login cooldown to stderr.
+func countdown(wait, _ time.Duration) {
+	go func() {
+		for left := wait; left > 0; left -= time.Second {
+			fmt.Fprintf(os.Stderr, "\rLogin blocked by the device, retrying in %s ", left)
+			time.Sleep(time.Second)
+		}
+		fmt.Fprintln(os.Stderr)
+	}()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/agent"
+)
+
+func NewAgentCommand() *AgentCommand {
+	gc := &AgentCommand{
+		fs: flag.NewFlagSet("agent", flag.ContinueOnError),
+	}
+
+	gc.fs.StringVar(&gc.username, "username", "", "(Optional) Provide username for authentication, defaults to the user last logged in")
+	gc.fs.StringVar(&gc.socket, "socket", agent.DefaultSocketPath(), "Provide path of the Unix socket the agent listens on")
+	gc.fs.DurationVar(&gc.keepAlive, "keep-alive", fritzos.DefaultKeepAliveInterval, "Provide interval in which the session is refreshed")
+
+	return gc
+}
+
+type AgentCommand struct {
+	fs *flag.FlagSet
+
+	username  string
+	socket    string
+	keepAlive time.Duration
+}
+
+func (g *AgentCommand) Name() string {
+	return g.fs.Name()
+}
+
+func (g *AgentCommand) Init(args []string) error {
+	return g.fs.Parse(args)
+}
+
+func (g *AgentCommand) Run() error {
+	return exampleAgent(g)
+}
+
+func exampleAgent(g *AgentCommand) error {
+	// The agent keeps the credentials, so it can renew the session when it expires
+	c := newLoginClient()
+	if err := loginWithProvider(c, g.username); err != nil {
+		return err
+	}
+	defer c.Close()
+
+	c.KeepAlive(g.keepAlive)
+
+	srv := agent.NewServer(c)
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		srv.Close()
+	}()
+
+	log.Printf("Agent listening on %s, use it with %s=%s", g.socket, agentSocketEnv, g.socket)
+	return srv.ListenAndServe(g.socket)
+}
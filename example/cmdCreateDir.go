@@ -5,9 +5,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"log"
 
-	"github.com/rumenvasilev/go-fritzos/auth"
 	"github.com/rumenvasilev/go-fritzos/nas"
 )
 
@@ -16,8 +14,7 @@ func NewCreateDirCommand() *CreateDirCommand {
 		fs: flag.NewFlagSet("createdir", flag.ContinueOnError),
 	}
 
-	gc.fs.StringVar(&gc.username, "username", "", "Provide username for authentication")
-	gc.fs.StringVar(&gc.password, "password", "", "Provide password for authentication")
+	gc.fs.StringVar(&gc.username, "username", "", "(Optional) Provide username for authentication, defaults to the user last logged in")
 	gc.fs.StringVar(&gc.name, "name", "", "Provide name for the new directory you want to create")
 	gc.fs.StringVar(&gc.remotePath, "remote-path", "", "Provide full path where the new directory will be created on the remote target")
 
@@ -28,7 +25,6 @@ type CreateDirCommand struct {
 	fs *flag.FlagSet
 
 	username   string
-	password   string
 	name       string
 	remotePath string
 }
@@ -53,16 +49,14 @@ func exampleCreateDir(g *CreateDirCommand) error {
 		return errors.New("Please specify -remote-path")
 	}
 
-	sess, err := auth.Auth(g.username, g.password)
+	c, err := newClient(g.username)
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
-
-	log.Println("Login successful! Session ID", sess)
+	defer c.Close()
 
 	// Create Dir
-	n := nas.New(sess).WithAddress("http://fritz.box")
+	n := nas.NewWithClient(c)
 	r, err := n.CreateDir(g.name, g.remotePath)
 	if err != nil {
 		var fse *nas.SystemError
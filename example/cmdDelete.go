@@ -4,9 +4,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"log"
 
-	"github.com/rumenvasilev/go-fritzos/auth"
 	"github.com/rumenvasilev/go-fritzos/nas"
 )
 
@@ -15,8 +13,7 @@ func NewDeleteCommand() *DeleteCommand {
 		fs: flag.NewFlagSet("delete", flag.ContinueOnError),
 	}
 
-	gc.fs.StringVar(&gc.username, "username", "", "Provide username for authentication")
-	gc.fs.StringVar(&gc.password, "password", "", "Provide password for authentication")
+	gc.fs.StringVar(&gc.username, "username", "", "(Optional) Provide username for authentication, defaults to the user last logged in")
 	gc.fs.StringVar(&gc.remotePath, "remote-path", "", "Provide path (remote) to the file you want to delete")
 
 	return gc
@@ -26,7 +23,6 @@ type DeleteCommand struct {
 	fs *flag.FlagSet
 
 	username   string
-	password   string
 	remotePath string
 }
 
@@ -47,16 +43,14 @@ func exampleDelete(g *DeleteCommand) error {
 		return errors.New("Please specify -path")
 	}
 
-	sess, err := auth.Auth(g.username, g.password)
+	c, err := newClient(g.username)
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
-
-	log.Println("Login successful! Session ID", sess)
+	defer c.Close()
 
 	// Create client
-	n := nas.New(sess).WithAddress("http://fritz.box")
+	n := nas.NewWithClient(c)
 
 	// Delete File
 	r, err := n.DeleteObject(g.remotePath)
@@ -1,15 +1,12 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"log"
-	"os"
 	"strings"
 
-	"github.com/rumenvasilev/go-fritzos/auth"
 	"github.com/rumenvasilev/go-fritzos/nas"
 )
 
@@ -18,8 +15,7 @@ func NewGetFileCommand() *GetFileCommand {
 		fs: flag.NewFlagSet("getfile", flag.ContinueOnError),
 	}
 
-	gc.fs.StringVar(&gc.username, "username", "", "Provide username for authentication")
-	gc.fs.StringVar(&gc.password, "password", "", "Provide password for authentication")
+	gc.fs.StringVar(&gc.username, "username", "", "(Optional) Provide username for authentication, defaults to the user last logged in")
 	gc.fs.StringVar(&gc.path, "path", "", "(Optional) Provide full path to the file you want to get")
 
 	return gc
@@ -29,7 +25,6 @@ type GetFileCommand struct {
 	fs *flag.FlagSet
 
 	username string
-	password string
 	path     string
 }
 
@@ -50,30 +45,24 @@ func exampleGetFile(g *GetFileCommand) error {
 		return errors.New("Please specify -path and full path to a filename available in the target device.")
 	}
 
-	sess, err := auth.Auth(g.username, g.password)
+	c, err := newClient(g.username)
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
-
-	log.Println("Login successful! Session ID", sess)
+	defer c.Close()
 
 	// Create client
-	n := nas.New(sess).WithAddress("http://fritz.box")
-
-	// Get specific object from the NAS
-	d, err := n.GetFile(g.path)
-	if err != nil {
-		return err
-	}
-
-	data, _ := io.ReadAll(d)
-	defer d.Close()
+	n := nas.NewWithClient(c)
 
+	// Download to the current directory. An interrupted download leaves a .part file,
+	// which is resumed by the next run.
 	f := strings.Split(g.path, "/")
-	err = os.WriteFile(f[len(f)-1], data, 0755)
+	name := f[len(f)-1]
+	err = n.DownloadFile(context.Background(), g.path, name, nas.DownloadOptions{
+		Progress: newProgressBar(name),
+	})
 	if err != nil {
-		return fmt.Errorf("failed writing the resulting file to disk, %w", err)
+		return fmt.Errorf("failed downloading the file, %w", err)
 	}
 
 	return nil
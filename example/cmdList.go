@@ -4,9 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"log"
 
-	"github.com/rumenvasilev/go-fritzos/auth"
 	"github.com/rumenvasilev/go-fritzos/nas"
 )
 
@@ -15,8 +13,7 @@ func NewListFilesCommand() *ListFilesCommand {
 		fs: flag.NewFlagSet("list", flag.ContinueOnError),
 	}
 
-	gc.fs.StringVar(&gc.username, "username", "", "Provide username for authentication")
-	gc.fs.StringVar(&gc.password, "password", "", "Provide password for authentication")
+	gc.fs.StringVar(&gc.username, "username", "", "(Optional) Provide username for authentication, defaults to the user last logged in")
 	gc.fs.StringVar(&gc.path, "path", "", "(Optional) Provide path you want to list")
 
 	return gc
@@ -26,7 +23,6 @@ type ListFilesCommand struct {
 	fs *flag.FlagSet
 
 	username string
-	password string
 	path     string
 }
 
@@ -43,16 +39,14 @@ func (g *ListFilesCommand) Run() error {
 }
 
 func exampleList(g *ListFilesCommand) error {
-	sess, err := auth.Auth(g.username, g.password)
+	c, err := newClient(g.username)
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
-
-	log.Println("Login successful! Session ID", sess)
+	defer c.Close()
 
 	// Create client
-	n := nas.New(sess).WithAddress("http://fritz.box")
+	n := nas.NewWithClient(c)
 
 	p := "/"
 	if g.path != "" {
@@ -5,9 +5,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"log"
 
-	"github.com/rumenvasilev/go-fritzos/auth"
 	"github.com/rumenvasilev/go-fritzos/nas"
 )
 
@@ -16,8 +14,7 @@ func NewMoveCommand() *MoveCommand {
 		fs: flag.NewFlagSet("move", flag.ContinueOnError),
 	}
 
-	gc.fs.StringVar(&gc.username, "username", "", "Provide username for authentication")
-	gc.fs.StringVar(&gc.password, "password", "", "Provide password for authentication")
+	gc.fs.StringVar(&gc.username, "username", "", "(Optional) Provide username for authentication, defaults to the user last logged in")
 	gc.fs.StringVar(&gc.from, "from", "", "Provide (remote) path to the object (file or dir) you want to move")
 	gc.fs.StringVar(&gc.to, "to", "", "Provide new directory path for the object (file or dir) you want to move")
 
@@ -28,7 +25,6 @@ type MoveCommand struct {
 	fs *flag.FlagSet
 
 	username string
-	password string
 	from     string
 	to       string
 }
@@ -53,16 +49,14 @@ func exampleMove(g *MoveCommand) error {
 		return errors.New("Please specify -to")
 	}
 
-	sess, err := auth.Auth(g.username, g.password)
+	c, err := newClient(g.username)
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
-
-	log.Println("Login successful! Session ID", sess)
+	defer c.Close()
 
 	// Create client
-	n := nas.New(sess).WithAddress("http://fritz.box")
+	n := nas.NewWithClient(c)
 
 	// Move File
 	r, err := n.MoveObject(g.to, g.from)
@@ -1,14 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
 
-	"github.com/rumenvasilev/go-fritzos/auth"
 	"github.com/rumenvasilev/go-fritzos/nas"
 )
 
@@ -17,8 +17,7 @@ func NewPutFileCommand() *PutFileCommand {
 		fs: flag.NewFlagSet("putfile", flag.ContinueOnError),
 	}
 
-	gc.fs.StringVar(&gc.username, "username", "", "Provide username for authentication")
-	gc.fs.StringVar(&gc.password, "password", "", "Provide password for authentication")
+	gc.fs.StringVar(&gc.username, "username", "", "(Optional) Provide username for authentication, defaults to the user last logged in")
 	gc.fs.StringVar(&gc.path, "path", "", "Provide full path to the file you want to upload")
 	gc.fs.StringVar(&gc.remotePath, "remote-path", "", "Provide full path where your file will be placed on the remote target")
 
@@ -29,7 +28,6 @@ type PutFileCommand struct {
 	fs *flag.FlagSet
 
 	username   string
-	password   string
 	path       string
 	remotePath string
 }
@@ -54,24 +52,29 @@ func examplePutFile(g *PutFileCommand) error {
 		return errors.New("Please specify -remote-path")
 	}
 
-	sess, err := auth.Auth(g.username, g.password)
+	c, err := newClient(g.username)
 	if err != nil {
 		return err
 	}
-	defer sess.Close()
+	defer c.Close()
 
-	log.Println("Login successful! Session ID", sess)
+	if !c.Session().Rights.NAS.CanWrite() {
+		return errors.New("user has no write access to the NAS")
+	}
 
 	// Create client
-	n := nas.New(sess).WithAddress("http://fritz.box")
+	n := nas.NewWithClient(c)
 
 	// Put File
 	data, err := os.Open(g.path)
 	if err != nil {
 		return err
 	}
+	defer data.Close()
 
-	r, err := n.PutFile(g.remotePath, data)
+	r, err := n.PutFileWithOptions(context.Background(), g.remotePath, data, nas.PutFileOptions{
+		Progress: newProgressBar(filepath.Base(g.path)),
+	})
 	if err != nil {
 		return fmt.Errorf("failed uploading the file, %w", err)
 	}
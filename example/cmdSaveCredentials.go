@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rumenvasilev/go-fritzos/auth"
+)
+
+func NewSaveCredentialsCommand() *SaveCredentialsCommand {
+	gc := &SaveCredentialsCommand{
+		fs: flag.NewFlagSet("save-credentials", flag.ContinueOnError),
+	}
+
+	gc.fs.StringVar(&gc.username, "username", "", "(Optional) Provide username for authentication, defaults to the user last logged in")
+
+	return gc
+}
+
+type SaveCredentialsCommand struct {
+	fs *flag.FlagSet
+
+	username string
+}
+
+func (g *SaveCredentialsCommand) Name() string {
+	return g.fs.Name()
+}
+
+func (g *SaveCredentialsCommand) Init(args []string) error {
+	return g.fs.Parse(args)
+}
+
+func (g *SaveCredentialsCommand) Run() error {
+	return exampleSaveCredentials(g)
+}
+
+func exampleSaveCredentials(g *SaveCredentialsCommand) error {
+	path := credentialsFile()
+	if path == "" {
+		return fmt.Errorf("please specify the credentials file with %s", credentialsFileEnv)
+	}
+
+	// The credentials are looked up by the address of the client, see FRITZ_ADDRESS
+	address := newBaseClient().Address()
+
+	p := &auth.PromptProvider{Username: g.username}
+	creds, err := p.Credentials(context.Background(), address)
+	if err != nil {
+		return err
+	}
+
+	pass, err := passphrase()
+	if err != nil {
+		return err
+	}
+
+	if err := auth.SaveEncryptedCredentials(path, pass, address, creds); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Credentials saved to", path)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+func NewUsersCommand() *UsersCommand {
+	gc := &UsersCommand{
+		fs: flag.NewFlagSet("users", flag.ContinueOnError),
+	}
+
+	return gc
+}
+
+type UsersCommand struct {
+	fs *flag.FlagSet
+}
+
+func (g *UsersCommand) Name() string {
+	return g.fs.Name()
+}
+
+func (g *UsersCommand) Init(args []string) error {
+	return g.fs.Parse(args)
+}
+
+func (g *UsersCommand) Run() error {
+	return exampleUsers()
+}
+
+func exampleUsers() error {
+	c := newBaseClient()
+
+	rctx, cancel := context.WithTimeout(context.Background(), c.Timeouts().Request)
+	defer cancel()
+
+	users, err := c.ListUsers(rctx)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		fmt.Println(u)
+	}
+
+	return nil
+}
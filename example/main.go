@@ -27,6 +27,9 @@ func root(args []string) error {
 		NewDeleteCommand(),
 		NewMoveCommand(),
 		NewCreateDirCommand(),
+		NewUsersCommand(),
+		NewSaveCredentialsCommand(),
+		NewAgentCommand(),
 	}
 
 	subcommand := os.Args[1]
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rumenvasilev/go-fritzos/nas"
+	"golang.org/x/term"
+)
+
+const progressBarWidth = 30
+
+// newProgressBar renders the progress of a transfer to stderr. It returns nil, if stderr
+// isn't a terminal, so the output of scripts isn't cluttered.
+func newProgressBar(name string) nas.ProgressFunc {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+
+	return func(p nas.Progress) {
+		line := fmt.Sprintf("%s %s/s", formatBytes(p.Transferred), formatBytes(int64(p.Rate)))
+		if pct := p.Percent(); pct >= 0 {
+			filled := min(max(int(pct*progressBarWidth/100), 0), progressBarWidth)
+			bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+			line = fmt.Sprintf("[%s] %3.0f%% %s/%s %s/s", bar, pct, formatBytes(p.Transferred), formatBytes(p.Total), formatBytes(int64(p.Rate)))
+			if p.ETA > 0 {
+				line += " ETA " + p.ETA.Round(time.Second).String()
+			}
+		}
+
+		// pad, so the remains of a longer line are overwritten
+		fmt.Fprintf(os.Stderr, "\r%s %-80s", name, line)
+		if p.Done {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,126 @@
+package fritztest
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type node struct {
+	dir     bool
+	data    []byte
+	modTime time.Time
+}
+
+// memFS is the in-memory storage of the fake NAS, keyed by cleaned absolute paths.
+type memFS struct {
+	mu    sync.Mutex
+	nodes map[string]*node
+}
+
+func newMemFS() *memFS {
+	return &memFS{
+		nodes: map[string]*node{"/": {dir: true, modTime: time.Now()}},
+	}
+}
+
+func cleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// WriteFile stores data at path, creating missing parent directories.
+func (s *Server) WriteFile(p string, data []byte) {
+	s.fs.mu.Lock()
+	defer s.fs.mu.Unlock()
+
+	p = cleanPath(p)
+	s.fs.mkdirAll(path.Dir(p))
+	s.fs.nodes[p] = &node{data: append([]byte(nil), data...), modTime: time.Now()}
+}
+
+// Mkdir creates the directory at path, including missing parents.
+func (s *Server) Mkdir(p string) {
+	s.fs.mu.Lock()
+	defer s.fs.mu.Unlock()
+	s.fs.mkdirAll(cleanPath(p))
+}
+
+// ReadFile returns the content of the file at path.
+func (s *Server) ReadFile(p string) ([]byte, error) {
+	s.fs.mu.Lock()
+	defer s.fs.mu.Unlock()
+
+	n, ok := s.fs.nodes[cleanPath(p)]
+	if !ok || n.dir {
+		return nil, fs.ErrNotExist
+	}
+	return append([]byte(nil), n.data...), nil
+}
+
+// Exists reports whether a file or directory exists at path.
+func (s *Server) Exists(p string) bool {
+	s.fs.mu.Lock()
+	defer s.fs.mu.Unlock()
+	_, ok := s.fs.nodes[cleanPath(p)]
+	return ok
+}
+
+// mkdirAll must be called with m.mu held.
+func (m *memFS) mkdirAll(p string) {
+	for dir := p; ; dir = path.Dir(dir) {
+		if _, ok := m.nodes[dir]; !ok {
+			m.nodes[dir] = &node{dir: true, modTime: time.Now()}
+		}
+		if dir == "/" {
+			return
+		}
+	}
+}
+
+// children returns the sorted paths of the direct children of dir. Must be called with m.mu held.
+func (m *memFS) children(dir string) []string {
+	var out []string
+	for p := range m.nodes {
+		if p != "/" && path.Dir(p) == dir {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// move renames the subtree at from to to. Must be called with m.mu held.
+func (m *memFS) move(from, to string) {
+	moved := make(map[string]*node)
+	for p, n := range m.nodes {
+		if p == from || strings.HasPrefix(p, from+"/") {
+			delete(m.nodes, p)
+			moved[to+strings.TrimPrefix(p, from)] = n
+		}
+	}
+
+	for p, n := range moved {
+		m.nodes[p] = n
+	}
+}
+
+// remove deletes the subtree at p. Must be called with m.mu held.
+func (m *memFS) remove(p string) {
+	for q := range m.nodes {
+		if q == p || strings.HasPrefix(q, p+"/") {
+			delete(m.nodes, q)
+		}
+	}
+}
+
+// usage returns the bytes stored. Must be called with m.mu held.
+func (m *memFS) usage() int {
+	var used int
+	for _, n := range m.nodes {
+		used += len(n.data)
+	}
+	return used
+}
@@ -0,0 +1,212 @@
+package fritztest
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/rumenvasilev/go-fritzos/auth"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const emptySID = "0000000000000000"
+
+type sessionInfo struct {
+	XMLName   xml.Name `xml:"SessionInfo"`
+	SID       string
+	Challenge string
+	BlockTime int
+	Rights    rights
+	Users     []user `xml:"Users>User"`
+}
+
+// rights encodes as the alternating Name and Access elements of the device.
+type rights struct {
+	names  []string
+	access []auth.Access
+}
+
+func (r rights) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for i, name := range r.names {
+		if err := e.EncodeElement(name, xml.StartElement{Name: xml.Name{Local: "Name"}}); err != nil {
+			return err
+		}
+		if err := e.EncodeElement(int(r.access[i]), xml.StartElement{Name: xml.Name{Local: "Access"}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+type user struct {
+	Value string `xml:",chardata"`
+	Last  int    `xml:"last,attr,omitempty"`
+}
+
+// handleLogin implements login_sid.lua: challenges, responses, logout and session checks.
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := s.sessionInfo(emptySID)
+
+	switch {
+	case r.Form.Has("logout"):
+		delete(s.sessions, r.Form.Get("logout"))
+	case r.Form.Has("response"):
+		if sid, ok := s.respond(r.Form.Get("username"), r.Form.Get("response")); ok {
+			info = s.sessionInfo(sid)
+		} else {
+			info.BlockTime = s.blockTime()
+		}
+	case r.Form.Has("sid"):
+		if _, ok := s.sessions[r.Form.Get("sid")]; ok {
+			info = s.sessionInfo(r.Form.Get("sid"))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(info)
+}
+
+// sessionInfo returns the SessionInfo of sid. Must be called with s.mu held.
+func (s *Server) sessionInfo(sid string) *sessionInfo {
+	info := &sessionInfo{
+		SID:       sid,
+		Challenge: s.currentChallenge(),
+		BlockTime: s.blockTime(),
+	}
+
+	if sid != emptySID {
+		info.Rights = encodeRights(s.rights)
+	}
+
+	names := make([]string, 0, len(s.users))
+	for name := range s.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		u := user{Value: name}
+		if name == s.lastUser {
+			u.Last = 1
+		}
+		info.Users = append(info.Users, u)
+	}
+
+	return info
+}
+
+// respond checks the response to the current challenge and creates a session.
+// Must be called with s.mu held.
+func (s *Server) respond(username, response string) (string, bool) {
+	if s.blockTime() > 0 {
+		return "", false
+	}
+
+	if username == "" {
+		username = s.lastUser
+	}
+
+	password, ok := s.users[username]
+	if !ok || response != s.expectedResponse(password) {
+		s.failures++
+		if s.penalty > 0 {
+			s.blocked = time.Now().Add(s.penalty << (s.failures - 1))
+		}
+		s.challenge = ""
+		return "", false
+	}
+
+	s.failures = 0
+	s.challenge = ""
+	s.lastUser = username
+
+	sid := randomHex(8)
+	s.sessions[sid] = username
+	return sid, true
+}
+
+// blockTime returns the remaining seconds of the login cooldown.
+func (s *Server) blockTime() int {
+	left := time.Until(s.blocked)
+	if left <= 0 {
+		return 0
+	}
+	return int((left + time.Second - 1) / time.Second)
+}
+
+// currentChallenge returns the challenge of the next login, generating a new one if needed.
+func (s *Server) currentChallenge() string {
+	if s.challenge != "" {
+		return s.challenge
+	}
+
+	if s.version == 1 {
+		s.challenge = randomHex(4)
+	} else {
+		// Few iterations keep the tests fast, the device uses tens of thousands
+		s.challenge = fmt.Sprintf("2$1000$%s$100$%s", randomHex(16), randomHex(16))
+	}
+	return s.challenge
+}
+
+func (s *Server) expectedResponse(password string) string {
+	parts := strings.Split(s.challenge, "$")
+	if len(parts) != 5 {
+		codes := utf16.Encode([]rune(s.challenge + "-" + password))
+		b := make([]byte, len(codes)*2)
+		for i, r := range codes {
+			b[i*2] = byte(r)
+			b[i*2+1] = byte(r >> 8)
+		}
+		return fmt.Sprintf("%s-%x", s.challenge, md5.Sum(b))
+	}
+
+	iter1, _ := strconv.Atoi(parts[1])
+	salt1, _ := hex.DecodeString(parts[2])
+	iter2, _ := strconv.Atoi(parts[3])
+	salt2, _ := hex.DecodeString(parts[4])
+
+	key1 := pbkdf2.Key([]byte(password), salt1, iter1, 32, sha256.New)
+	key2 := pbkdf2.Key(key1, salt2, iter2, 32, sha256.New)
+	return fmt.Sprintf("%s$%x", parts[4], key2)
+}
+
+// encodeRights returns the alternating Name and Access elements of r.
+func encodeRights(r auth.Rights) rights {
+	var out rights
+	for _, right := range []struct {
+		name   string
+		access auth.Access
+	}{
+		{"NAS", r.NAS}, {"App", r.App}, {"HomeAuto", r.HomeAuto},
+		{"BoxAdmin", r.BoxAdmin}, {"Phone", r.Phone}, {"Dial", r.Dial},
+	} {
+		if right.access > auth.AccessNone {
+			out.names = append(out.names, right.name)
+			out.access = append(out.access, right.access)
+		}
+	}
+	return out
+}
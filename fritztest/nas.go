@@ -0,0 +1,364 @@
+package fritztest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error codes reported by the file system controller of the device.
+const (
+	// CodeAlreadyExists is reported, e.g. when a folder to create exists.
+	CodeAlreadyExists = 5
+	// CodeInvalidParameter is reported for any failed rename.
+	CodeInvalidParameter = 9
+)
+
+// The device's codes for these errors are unknown, so they aren't exported.
+const (
+	codeNotFound = 2
+	codeInvalid  = 7
+)
+
+const contentTypeJSON = "application/json; charset=utf-8"
+
+type fsError struct {
+	Message string `json:"message"`
+	Path    string `json:"path"`
+	Code    int    `json:"code"`
+}
+
+// writeError responds with the error JSON of the device.
+func writeError(w http.ResponseWriter, status, code int, p, msg string) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"message": "[file_system_controller] " + msg,
+			"data":    fsError{Message: msg, Path: p, Code: code},
+			"code":    status,
+		},
+	})
+}
+
+// writeRenameError responds with the error JSON of a failed rename, which lists the errors in data.
+func writeRenameError(w http.ResponseWriter, p string) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(http.StatusBadRequest)
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"message": "[file_system_controller] An error occurred while renaming files or folders.",
+			"data": []fsError{{
+				Message: "Because one of the parameters submitted was incorrect, renaming cannot be carried out.",
+				Path:    p,
+				Code:    CodeInvalidParameter,
+			}},
+			"code": http.StatusBadRequest,
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// handleData implements nas/api/data.lua.
+func (s *Server) handleData(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !s.validSession(r.Form.Get("sid")) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	s.fs.mu.Lock()
+	defer s.fs.mu.Unlock()
+
+	switch r.Form.Get("a") {
+	case "browse":
+		s.browse(w, r)
+	case "create_dir":
+		s.createDir(w, r)
+	case "rename":
+		s.rename(w, r)
+	case "move":
+		s.move(w, r)
+	case "delete":
+		s.delete(w, r)
+	default:
+		writeError(w, http.StatusBadRequest, codeInvalid, "", fmt.Sprintf("Unknown action %q.", r.Form.Get("a")))
+	}
+}
+
+type entry struct {
+	Path        string `json:"path"`
+	Shared      bool   `json:"shared"`
+	StorageType string `json:"storageType"`
+	Type        string `json:"type"`
+	Timestamp   int64  `json:"timestamp"`
+	Filename    string `json:"filename"`
+	Size        *int   `json:"size,omitempty"`
+}
+
+func (m *memFS) entry(p string) entry {
+	n := m.nodes[p]
+	e := entry{
+		Path:        p,
+		StorageType: "internal_storage",
+		Type:        "directory",
+		Timestamp:   n.modTime.Unix(),
+		Filename:    path.Base(p),
+	}
+
+	if !n.dir {
+		size := len(n.data)
+		e.Type = "document"
+		e.Size = &size
+	}
+	return e
+}
+
+func (s *Server) browse(w http.ResponseWriter, r *http.Request) {
+	dir := cleanPath(r.Form.Get("path"))
+	if n, ok := s.fs.nodes[dir]; !ok || !n.dir {
+		writeError(w, http.StatusBadRequest, codeNotFound, dir, fmt.Sprintf("The folder %q does not exist.", dir))
+		return
+	}
+
+	children := s.fs.children(dir)
+
+	// start is the 0-based offset of the first entry, the response reports it 1-based
+	start, _ := strconv.Atoi(r.Form.Get("start"))
+	limit, _ := strconv.Atoi(r.Form.Get("limit"))
+	if start < 0 || start > len(children) {
+		start = len(children)
+	}
+	end := len(children)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	files := []entry{}
+	dirs := []entry{}
+	for _, p := range children[start:end] {
+		if s.fs.nodes[p].dir {
+			dirs = append(dirs, s.fs.entry(p))
+		} else {
+			files = append(files, s.fs.entry(p))
+		}
+	}
+
+	used := s.fs.usage()
+	writeJSON(w, map[string]any{
+		"diskInfo":    map[string]int{"used": used, "total": 1 << 30, "free": 1<<30 - used},
+		"files":       files,
+		"directories": dirs,
+		"writeRight":  true,
+		"browse": map[string]any{
+			"path":       dir,
+			"index":      start + 1,
+			"totalCount": len(children),
+			"finished":   end == len(children),
+			"mode":       "file",
+			"limit":      limit,
+			"sorting":    r.Form.Get("sorting"),
+		},
+	})
+}
+
+func (s *Server) createDir(w http.ResponseWriter, r *http.Request) {
+	parent := cleanPath(r.Form.Get("path"))
+	name := r.Form.Get("name")
+	if n, ok := s.fs.nodes[parent]; !ok || !n.dir {
+		writeError(w, http.StatusBadRequest, codeNotFound, parent, fmt.Sprintf("The folder %q does not exist.", parent))
+		return
+	}
+
+	p := path.Join(parent, name)
+	if _, ok := s.fs.nodes[p]; ok {
+		writeError(w, http.StatusBadRequest, CodeAlreadyExists, parent,
+			fmt.Sprintf("The folder with the name %q already exists and therefore cannot be created.", name))
+		return
+	}
+
+	s.fs.mkdirAll(p)
+	writeJSON(w, map[string]any{"directory": s.fs.entry(p)})
+}
+
+// indexedPaths returns the values of the parameters paths[1], paths[2], ... or
+// paths[1][field], paths[2][field], ... if field is not empty.
+func indexedPaths(r *http.Request, field string) []string {
+	var out []string
+	for i := 1; ; i++ {
+		key := fmt.Sprintf("paths[%d]", i)
+		if field != "" {
+			key += "[" + field + "]"
+		}
+		if !r.Form.Has(key) {
+			return out
+		}
+		out = append(out, r.Form.Get(key))
+	}
+}
+
+func (s *Server) rename(w http.ResponseWriter, r *http.Request) {
+	paths, names := indexedPaths(r, "path"), indexedPaths(r, "newName")
+
+	var count int
+	for i, from := range paths {
+		from = cleanPath(from)
+		if _, ok := s.fs.nodes[from]; !ok || i >= len(names) {
+			writeRenameError(w, from)
+			return
+		}
+
+		to := path.Join(path.Dir(from), path.Base(names[i]))
+		if _, ok := s.fs.nodes[to]; ok {
+			writeRenameError(w, from)
+			return
+		}
+
+		s.fs.move(from, to)
+		count++
+	}
+
+	writeJSON(w, map[string]int{"renameCount": count})
+}
+
+func (s *Server) move(w http.ResponseWriter, r *http.Request) {
+	target := cleanPath(r.Form.Get("target"))
+	if n, ok := s.fs.nodes[target]; !ok || !n.dir {
+		writeError(w, http.StatusBadRequest, codeNotFound, target, fmt.Sprintf("The folder %q does not exist.", target))
+		return
+	}
+
+	var count int
+	for _, from := range indexedPaths(r, "") {
+		from = cleanPath(from)
+		if _, ok := s.fs.nodes[from]; !ok {
+			writeError(w, http.StatusBadRequest, codeNotFound, from, fmt.Sprintf("The file or folder %q does not exist.", from))
+			return
+		}
+
+		to := path.Join(target, path.Base(from))
+		if _, ok := s.fs.nodes[to]; ok || target == from || strings.HasPrefix(target, from+"/") {
+			writeError(w, http.StatusBadRequest, CodeAlreadyExists, to, fmt.Sprintf("The name %q already exists.", path.Base(to)))
+			return
+		}
+
+		s.fs.move(from, to)
+		count++
+	}
+
+	writeJSON(w, map[string]int{"moveCount": count})
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	var count int
+	for _, p := range indexedPaths(r, "") {
+		p = cleanPath(p)
+		if _, ok := s.fs.nodes[p]; !ok || p == "/" {
+			writeError(w, http.StatusBadRequest, codeNotFound, p, fmt.Sprintf("The file or folder %q does not exist.", p))
+			return
+		}
+
+		s.fs.remove(p)
+		count++
+	}
+
+	writeJSON(w, map[string]int{"deleteCount": count})
+}
+
+// handleDownload implements luacgi_notimeout, serving files with Range support.
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !s.validSession(r.Form.Get("sid")) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	p := cleanPath(r.Form.Get("path"))
+
+	s.fs.mu.Lock()
+	n, ok := s.fs.nodes[p]
+	s.fs.mu.Unlock()
+
+	if !ok || n.dir {
+		writeError(w, http.StatusNotFound, codeNotFound, p, fmt.Sprintf("The file %q does not exist.", p))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, path.Base(p), n.modTime, strings.NewReader(string(n.data)))
+}
+
+// handleUpload implements nasupload_notimeout, which receives multipart uploads.
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fields := make(map[string]string)
+	result := map[string]string{"SuccessfulUploads": "0", "ResultCode": "0"}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if part.FileName() == "" {
+			fields[part.FormName()] = string(data)
+			continue
+		}
+
+		result["Filename"] = part.FileName()
+		result["sid"] = fields["sid"]
+		result["dir"] = fields["dir"]
+
+		// The device reports errors in the result code, not the status
+		if !s.validSession(fields["sid"]) {
+			result["ResultCode"] = "5"
+			continue
+		}
+
+		dir := cleanPath(fields["dir"])
+		s.fs.mu.Lock()
+		if n, ok := s.fs.nodes[dir]; !ok || !n.dir {
+			result["ResultCode"] = "9"
+		} else {
+			s.fs.nodes[path.Join(dir, path.Base(part.FileName()))] = &node{data: data, modTime: time.Now()}
+			result["SuccessfulUploads"] = "1"
+		}
+		s.fs.mu.Unlock()
+	}
+
+	writeJSON(w, result)
+}
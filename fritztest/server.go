@@ -0,0 +1,141 @@
+// Package fritztest provides an in-process fake of the device for tests. It
+// implements the login (v1 and v2 challenges, BlockTime) and the NAS API against
+// an in-memory filesystem, returning the error responses of a real device.
+//
+//	box := fritztest.NewServer(t).WithUser("fritz1234", "secret")
+//	c := box.NewClient()
+//	err := c.Login("fritz1234", "secret")
+package fritztest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/auth"
+)
+
+// Server is a fake device running on a local httptest.Server.
+// It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	users     map[string]string
+	lastUser  string
+	version   int
+	penalty   time.Duration
+	failures  int
+	blocked   time.Time
+	rights    auth.Rights
+	sessions  map[string]string // sid -> username
+	challenge string
+
+	fs *memFS
+}
+
+// NewServer starts a fake device, which is closed when the test ends.
+// It accepts v2 (PBKDF2) challenges, grants write access to all rights and
+// doesn't block failed logins, unless configured otherwise.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		users:    make(map[string]string),
+		version:  2,
+		sessions: make(map[string]string),
+		rights: auth.Rights{
+			NAS:      auth.AccessWrite,
+			App:      auth.AccessWrite,
+			HomeAuto: auth.AccessWrite,
+			BoxAdmin: auth.AccessWrite,
+			Phone:    auth.AccessWrite,
+			Dial:     auth.AccessWrite,
+		},
+		fs: newMemFS(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login_sid.lua", s.handleLogin)
+	mux.HandleFunc("/nas/api/data.lua", s.handleData)
+	mux.HandleFunc("/nas/cgi-bin/luacgi_notimeout", s.handleDownload)
+	mux.HandleFunc("/nas/cgi-bin/nasupload_notimeout", s.handleUpload)
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// WithUser adds a user, which can log in with password. The first user added
+// is reported as the one last logged in.
+func (s *Server) WithUser(username, password string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[username] = password
+	if s.lastUser == "" {
+		s.lastUser = username
+	}
+	return s
+}
+
+// WithLoginVersion selects the challenge, 1 for MD5 (before FRITZ!OS 7.24) or 2 for PBKDF2.
+func (s *Server) WithLoginVersion(v int) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = v
+	s.challenge = ""
+	return s
+}
+
+// WithBlockTime makes failed logins block further attempts for d, doubled with
+// every consecutive failure, like the device does.
+func (s *Server) WithBlockTime(d time.Duration) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.penalty = d
+	return s
+}
+
+// WithRights sets the rights granted to new sessions.
+func (s *Server) WithRights(r auth.Rights) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rights = r
+	return s
+}
+
+// NewClient creates a client for the fake device.
+func (s *Server) NewClient() *fritzos.Client {
+	return fritzos.New().WithAddress(s.URL).WithHTTPClient(s.Client())
+}
+
+// ExpireSessions ends all sessions, like the device does after 20 minutes of inactivity.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]string)
+}
+
+// Sessions returns the number of active sessions.
+func (s *Server) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// validSession reports whether sid belongs to an active session.
+func (s *Server) validSession(sid string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.sessions[sid]
+	return ok
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
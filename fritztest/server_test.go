@@ -0,0 +1,143 @@
+package fritztest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/rumenvasilev/go-fritzos/auth"
+	"github.com/rumenvasilev/go-fritzos/fritztest"
+	"github.com/rumenvasilev/go-fritzos/nas"
+)
+
+func TestLogin(t *testing.T) {
+	for _, version := range []int{1, 2} {
+		is := is.New(t)
+
+		box := fritztest.NewServer(t).WithUser("fritz1234", "secret").WithLoginVersion(version)
+		c := box.NewClient()
+
+		is.NoErr(c.Login("fritz1234", "secret"))
+		is.True(c.Session().Rights.NAS.CanWrite())
+		is.Equal(box.Sessions(), 1)
+		is.NoErr(c.Refresh(context.Background()))
+
+		is.NoErr(c.Close())
+		is.Equal(box.Sessions(), 0)
+	}
+}
+
+func TestLoginLastUser(t *testing.T) {
+	is := is.New(t)
+
+	box := fritztest.NewServer(t).WithUser("fritz1234", "secret").WithUser("other", "pass")
+	c := box.NewClient()
+
+	users, err := c.ListUsers(context.Background())
+	is.NoErr(err)
+	is.Equal(users, []string{"fritz1234", "other"})
+
+	is.NoErr(c.Login("", "secret"))
+}
+
+func TestLoginBlockTime(t *testing.T) {
+	is := is.New(t)
+
+	box := fritztest.NewServer(t).WithUser("fritz1234", "secret").WithBlockTime(2 * time.Second)
+	c := box.NewClient()
+
+	err := c.Login("fritz1234", "wrong")
+	var bte *auth.BlockTimeError
+	is.True(errors.As(err, &bte))
+	is.Equal(bte.Duration, 2)
+
+	// even the right password is rejected during the cooldown
+	err = c.Login("fritz1234", "secret")
+	is.True(errors.As(err, &bte))
+}
+
+func TestNAS(t *testing.T) {
+	is := is.New(t)
+
+	box := fritztest.NewServer(t).WithUser("fritz1234", "secret")
+	box.WriteFile("/Dokumente/a.txt", []byte("hello"))
+
+	c := box.NewClient()
+	is.NoErr(c.Login("fritz1234", "secret"))
+	n := nas.NewWithClient(c)
+
+	res, err := n.ListDirectory("/")
+	is.NoErr(err)
+	is.Equal(len(res.Directories), 1)
+	is.Equal(res.Directories[0].Path, "/Dokumente")
+	is.True(res.Browse.Finished)
+
+	_, err = n.CreateDir("Backup", "/")
+	is.NoErr(err)
+
+	_, err = n.CreateDir("Backup", "/")
+	var fse *nas.FileSystemControllerError
+	is.True(errors.As(err, &fse))
+	is.Equal(fse.Code, fritztest.CodeAlreadyExists)
+
+	put, err := n.PutFile("/Backup/b.txt", bytes.NewReader([]byte("data")))
+	is.NoErr(err)
+	is.Equal(put.SuccessfulUploads, nas.UploadResultOK)
+
+	rc, err := n.GetFile("/Backup/b.txt")
+	is.NoErr(err)
+	data, err := io.ReadAll(rc)
+	is.NoErr(err)
+	rc.Close()
+	is.Equal(string(data), "data")
+
+	count, err := n.RenameObject([]*nas.RenameInput{{From: "/Backup/b.txt", To: "c.txt"}})
+	is.NoErr(err)
+	is.Equal(count, 1)
+
+	count, err = n.MoveObject("/Dokumente", "/Backup/c.txt")
+	is.NoErr(err)
+	is.Equal(count, 1)
+	is.True(box.Exists("/Dokumente/c.txt"))
+
+	count, err = n.DeleteObject("/Backup", "/Dokumente/a.txt")
+	is.NoErr(err)
+	is.Equal(count, 2)
+	is.True(!box.Exists("/Backup"))
+
+	_, err = n.GetFile("/Dokumente/a.txt")
+	is.True(errors.As(err, &fse))
+	is.Equal(fse.Path, "/Dokumente/a.txt")
+
+	// rename errors are sent as a list, like the device does
+	_, err = n.RenameObject([]*nas.RenameInput{{From: "/Dokumente/missing.pdf", To: "a.pdf"}})
+	is.True(errors.As(err, &fse))
+	is.Equal(fse.Code, fritztest.CodeInvalidParameter)
+	is.Equal(fse.Path, "/Dokumente/missing.pdf")
+
+	t.Run("upload to missing directory", func(t *testing.T) {
+		is := is.New(t)
+
+		put, err := n.PutFile("/Missing/b.txt", bytes.NewReader([]byte("data")))
+		is.NoErr(err)
+		is.Equal(put.ResultCode, nas.ResultCodeDirNotExist)
+	})
+}
+
+func TestNASExpiredSession(t *testing.T) {
+	is := is.New(t)
+
+	box := fritztest.NewServer(t).WithUser("fritz1234", "secret")
+	c := box.NewClient()
+	is.NoErr(c.Login("fritz1234", "secret"))
+
+	box.ExpireSessions()
+
+	_, err := nas.NewWithClient(c).ListDirectory("/")
+	is.NoErr(err) // re-authenticated and replayed
+	is.Equal(box.Sessions(), 1)
+}
@@ -0,0 +1,83 @@
+package fritzos
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rumenvasilev/go-fritzos/auth"
+)
+
+// Refresh validates the client's session, which also resets its inactivity timer
+// on the device. An expired session is renewed with the stored credentials.
+func (c *Client) Refresh(ctx context.Context) error {
+	sess := c.Session()
+	if sess == nil {
+		return auth.ErrSessionExpired
+	}
+
+	err := sess.ValidateWithClient(ctx, c.Requester(), c.Address())
+	if errors.Is(err, auth.ErrSessionExpired) {
+		return c.Reauthenticate(ctx, sess.String())
+	}
+
+	return err
+}
+
+// DefaultKeepAliveInterval is used by KeepAlive for non-positive intervals. It's well
+// below the ~20 minutes of inactivity, after which the device expires sessions.
+const DefaultKeepAliveInterval = 5 * time.Minute
+
+// KeepAlive refreshes the session in the background every interval, so it
+// doesn't expire due to inactivity. It runs until Close is called.
+// Calling KeepAlive again replaces the running keep-alive.
+// A zero or negative interval is replaced by DefaultKeepAliveInterval.
+func (c *Client) KeepAlive(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultKeepAliveInterval
+	}
+	c.stopKeepAliveLoop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	c.mu.Lock()
+	c.stopKeepAlive = func() {
+		cancel()
+		<-done
+	}
+	c.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		c.keepAlive(ctx, interval)
+	}()
+}
+
+func (c *Client) keepAlive(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			rctx, cancel := context.WithTimeout(ctx, c.Timeouts().Request)
+			_ = c.Refresh(rctx)
+			cancel()
+		}
+	}
+}
+
+// stopKeepAliveLoop stops the running keep-alive and waits for it to finish.
+func (c *Client) stopKeepAliveLoop() {
+	c.mu.Lock()
+	stop := c.stopKeepAlive
+	c.stopKeepAlive = nil
+	c.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+}
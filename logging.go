@@ -0,0 +1,40 @@
+package fritzos
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/rumenvasilev/go-fritzos/request"
+)
+
+// loggingTransport logs method, path, status and duration of every request at debug level.
+type loggingTransport struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+}
+
+func (l *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !l.logger.Enabled(ctx, slog.LevelDebug) {
+		return l.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := l.next.RoundTrip(req)
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", request.RedactURL(req.URL)),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "request failed", attrs...)
+		return resp, err
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	l.logger.LogAttrs(ctx, slog.LevelDebug, "request", attrs...)
+	return resp, nil
+}
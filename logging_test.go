@@ -0,0 +1,48 @@
+package fritzos
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/rumenvasilev/go-fritzos/request"
+)
+
+func TestClientLogger(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(loginHandler(t))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := New().WithAddress(ts.URL).WithUserAgent("fritz-test").WithLogger(logger)
+	is.NoErr(c.Login("user", testPassword))
+	is.NoErr(c.Refresh(context.Background())) // sends the sid in the query
+
+	out := buf.String()
+	is.Equal(strings.Count(out, "level=DEBUG"), 3)
+	is.True(strings.Contains(out, "method=POST"))
+	is.True(strings.Contains(out, "status=200"))
+	is.True(strings.Contains(out, "sid="+request.Redacted))
+	is.True(!strings.Contains(out, testSID))
+}
+
+func TestClientLoggerInfoLevel(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(loginHandler(t))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	c := New().WithAddress(ts.URL).WithUserAgent("fritz-test").WithLogger(logger)
+	is.NoErr(c.Login("user", testPassword))
+	is.Equal(buf.Len(), 0) // requests are logged at debug level only
+}
@@ -0,0 +1,148 @@
+// Package metrics records the activity of a fritzos.Client as Prometheus metrics.
+//
+//	collector := metrics.NewCollector()
+//	prometheus.MustRegister(collector)
+//	c := fritzos.New().WithObserver(collector)
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	fritzos "github.com/rumenvasilev/go-fritzos"
+)
+
+const namespace = "fritzos"
+
+// Collector is a fritzos.Observer, which can be registered with a prometheus.Registerer.
+type Collector struct {
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+
+	operations        *prometheus.CounterVec
+	operationErrors   *prometheus.CounterVec
+	operationDuration *prometheus.HistogramVec
+	bytesUploaded     *prometheus.CounterVec
+	bytesDownloaded   *prometheus.CounterVec
+
+	logins    *prometheus.CounterVec
+	blockTime prometheus.Counter
+}
+
+var _ fritzos.Observer = (*Collector)(nil)
+
+// NewCollector creates the collector with all metrics prefixed with fritzos_.
+func NewCollector() *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "HTTP requests sent to the device, by method, path and status code.",
+		}, []string{"method", "path", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of HTTP requests until the response headers arrived.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "path"}),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_total",
+			Help:      "Operations run, e.g. nas.ListDirectory.",
+		}, []string{"operation"}),
+		operationErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operation_errors_total",
+			Help:      "Failed operations, by the error code reported by the device (0 if none).",
+		}, []string{"operation", "code"}),
+		operationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of operations, including re-authentication and retries.",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+		}, []string{"operation"}),
+		bytesUploaded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "uploaded_bytes_total",
+			Help:      "File data uploaded, e.g. by nas.PutFile.",
+		}, []string{"operation"}),
+		bytesDownloaded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "downloaded_bytes_total",
+			Help:      "File data downloaded, e.g. by nas.GetFile.",
+		}, []string{"operation"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_attempts_total",
+			Help:      "Login attempts, by result (success, failure, blocked).",
+		}, []string{"result"}),
+		blockTime: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_block_seconds_total",
+			Help:      "Login cooldown (BlockTime) enforced by the device.",
+		}),
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.requests, c.requestDuration,
+		c.operations, c.operationErrors, c.operationDuration, c.bytesUploaded, c.bytesDownloaded,
+		c.logins, c.blockTime,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.collectors() {
+		m.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.collectors() {
+		m.Collect(ch)
+	}
+}
+
+// ObserveRequest implements fritzos.Observer.
+func (c *Collector) ObserveRequest(e fritzos.RequestEvent) {
+	code := "error"
+	if e.Err == nil {
+		code = strconv.Itoa(e.Status)
+	}
+
+	c.requests.WithLabelValues(e.Method, e.Path, code).Inc()
+	c.requestDuration.WithLabelValues(e.Method, e.Path).Observe(e.Duration.Seconds())
+}
+
+// ObserveOperation implements fritzos.Observer.
+func (c *Collector) ObserveOperation(e fritzos.OperationEvent) {
+	c.operations.WithLabelValues(e.Name).Inc()
+	c.operationDuration.WithLabelValues(e.Name).Observe(e.Duration.Seconds())
+
+	if e.Err != nil {
+		c.operationErrors.WithLabelValues(e.Name, strconv.Itoa(e.ErrorCode)).Inc()
+	}
+
+	if e.BytesSent > 0 {
+		c.bytesUploaded.WithLabelValues(e.Name).Add(float64(e.BytesSent))
+	}
+	if e.BytesReceived > 0 {
+		c.bytesDownloaded.WithLabelValues(e.Name).Add(float64(e.BytesReceived))
+	}
+}
+
+// ObserveLogin implements fritzos.Observer.
+func (c *Collector) ObserveLogin(e fritzos.LoginEvent) {
+	switch {
+	case e.BlockTime > 0:
+		c.logins.WithLabelValues("blocked").Inc()
+		c.blockTime.Add(e.BlockTime.Seconds())
+	case e.Err != nil:
+		c.logins.WithLabelValues("failure").Inc()
+	default:
+		c.logins.WithLabelValues("success").Inc()
+	}
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/nas"
+)
+
+const fsError = `{"error":{"message":"exists","data":{"message":"exists","path":"/","code":5},"code":400}}`
+
+func TestCollector(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/login_sid.lua") {
+			w.Header().Set("Content-Type", "text/xml")
+			fmt.Fprint(w, "<SessionInfo><SID>2c21f7f4f060848e</SID><Challenge>1234567z</Challenge><BlockTime>0</BlockTime></SessionInfo>")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, fsError)
+	}))
+	defer ts.Close()
+
+	collector := NewCollector()
+	reg := prometheus.NewRegistry()
+	is.NoErr(reg.Register(collector))
+
+	c := fritzos.New().WithAddress(ts.URL).WithObserver(collector)
+	is.NoErr(c.Login("user", "secret"))
+
+	_, err := nas.NewWithClient(c).CreateDir("a", "/")
+	var se *nas.SystemError
+	is.True(errors.As(err, &se))
+
+	is.Equal(testutil.ToFloat64(collector.logins.WithLabelValues("success")), 1.0)
+	is.Equal(testutil.ToFloat64(collector.requests.WithLabelValues("POST", "/login_sid.lua", "200")), 1.0)
+	is.Equal(testutil.ToFloat64(collector.requests.WithLabelValues("POST", "/nas/api/data.lua", "400")), 1.0)
+	is.Equal(testutil.ToFloat64(collector.operations.WithLabelValues("nas.CreateDir")), 1.0)
+	is.Equal(testutil.ToFloat64(collector.operationErrors.WithLabelValues("nas.CreateDir", "5")), 1.0)
+
+	n, err := testutil.GatherAndCount(reg)
+	is.NoErr(err)
+	is.True(n > 0)
+}
+
+func TestCollectorBlockTime(t *testing.T) {
+	is := is.New(t)
+
+	collector := NewCollector()
+	collector.ObserveLogin(fritzos.LoginEvent{Err: errors.New("blocked"), BlockTime: 8 * time.Second})
+	collector.ObserveLogin(fritzos.LoginEvent{Err: errors.New("invalid")})
+
+	is.Equal(testutil.ToFloat64(collector.logins.WithLabelValues("blocked")), 1.0)
+	is.Equal(testutil.ToFloat64(collector.logins.WithLabelValues("failure")), 1.0)
+	is.Equal(testutil.ToFloat64(collector.blockTime), 8.0)
+}
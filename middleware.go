@@ -0,0 +1,84 @@
+package fritzos
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/rumenvasilev/go-fritzos/request"
+)
+
+// Middleware wraps the transport of the client, e.g. to inject headers, record
+// metrics or audit requests. Every request sent by auth and nas passes through it.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware adds mw to the middleware chain of the client. The first middleware
+// is the outermost one. They are called for every attempt of a retried request and
+// after the rate limit, but around the built-in logging.
+// Use request.RedactURL to log the URLs of requests without the session id.
+func (c *Client) WithMiddleware(mw ...Middleware) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.middleware = append(c.middleware, mw...)
+	c.hc = nil
+	return c
+}
+
+// LoggingMiddleware logs method, URL, status and duration of every request at
+// debug level, with the session id redacted. WithLogger adds it to the client.
+func LoggingMiddleware(l *slog.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &loggingTransport{next: next, logger: l}
+	}
+}
+
+// RetryMiddleware retries idempotent requests according to p. WithRetryPolicy
+// adds it to the client.
+func RetryMiddleware(p *RetryPolicy) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &retryTransport{next: next, policy: p}
+	}
+}
+
+// RedactSIDMiddleware replaces session ids in the errors of the wrapped transport,
+// so they don't leak into logs. The client always applies it as outermost middleware.
+func RedactSIDMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			msg := request.RedactSID(err.Error())
+			if msg != err.Error() {
+				err = &redactedError{err: err, msg: msg}
+			}
+		}
+		return resp, err
+	})
+}
+
+// redactedError replaces the message of err, but keeps it available to errors.Is and errors.As.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// chain wraps rt with the middlewares, the first one being the outermost.
+func chain(rt http.RoundTripper, mws ...Middleware) http.RoundTripper {
+	for i := len(mws) - 1; i >= 0; i-- {
+		rt = mws[i](rt)
+	}
+	return rt
+}
@@ -0,0 +1,67 @@
+package fritzos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestClientMiddleware(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.Header.Get("X-Trace"), "outer,inner")
+		loginHandler(t)(w, r)
+	}))
+	defer ts.Close()
+
+	var calls []string
+	header := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				req = req.Clone(req.Context())
+				trace := name
+				if v := req.Header.Get("X-Trace"); v != "" {
+					trace = v + "," + name
+				}
+				req.Header.Set("X-Trace", trace)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	c := New().WithAddress(ts.URL).WithUserAgent("fritz-test").WithMiddleware(header("outer"), header("inner"))
+	is.NoErr(c.Login("user", testPassword))
+	is.Equal(calls, []string{"outer", "inner", "outer", "inner"}) // challenge, response
+}
+
+func TestClientRedactsSID(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(loginHandler(t))
+	defer ts.Close()
+
+	errValidate := errors.New("validation failed")
+	fail := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Has("sid") {
+				return nil, fmt.Errorf("%w for %s", errValidate, req.URL)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+
+	c := New().WithAddress(ts.URL).WithUserAgent("fritz-test").WithMiddleware(fail)
+	is.NoErr(c.Login("user", testPassword))
+
+	err := c.Refresh(context.Background())
+	is.True(errors.Is(err, errValidate))
+	is.True(!strings.Contains(err.Error(), testSID))
+}
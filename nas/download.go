@@ -0,0 +1,125 @@
+package nas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// DownloadOptions configures DownloadFile.
+type DownloadOptions struct {
+	// Attempts is the number of requests made to complete the download, defaults to 3.
+	Attempts int
+	// Progress, if set, receives the progress of the download, including resumed bytes.
+	Progress ProgressFunc
+}
+
+// DownloadFile downloads the object at remotePath to localPath. The data is written to
+// localPath + ".part", which is renamed to localPath once its size matches the directory listing.
+// A broken transfer is resumed from the last byte written, both within the attempts of a call and
+// by later calls finding the .part file. If the device ignores the range, the download starts over.
+func (n *NAS) DownloadFile(ctx context.Context, remotePath, localPath string, opts DownloadOptions) error {
+	if opts.Attempts <= 0 {
+		opts.Attempts = 3
+	}
+
+	size, err := n.fileSize(ctx, remotePath)
+	if err != nil {
+		return err
+	}
+
+	partPath := localPath + ".part"
+	part, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer part.Close()
+
+	fi, err := part.Stat()
+	if err != nil {
+		return err
+	}
+	offset := fi.Size()
+
+	tracker := newProgressTracker(opts.Progress, size)
+	for attempt := 0; attempt < opts.Attempts && offset != size; attempt++ {
+		// The remote file has shrunk or changed, start over
+		if offset > size {
+			offset = 0
+		}
+
+		offset, err = n.resume(ctx, remotePath, part, offset, tracker)
+		if err == nil || !retryDownload(ctx, err) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if offset != size {
+		return fmt.Errorf("%w, got %d bytes, expected %d", ErrSizeMismatch, offset, size)
+	}
+	tracker.finish()
+
+	if err := part.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partPath, localPath)
+}
+
+// resume appends the object from offset to part and returns the new size of part.
+func (n *NAS) resume(ctx context.Context, remotePath string, part *os.File, offset int64, tracker *progressTracker) (int64, error) {
+	f, err := n.GetFileRange(ctx, remotePath, offset, 0)
+	if errors.Is(err, ErrRangeNotSatisfiable) {
+		// The .part file is longer than the remote file
+		f, err = n.GetFileRange(ctx, remotePath, 0, 0)
+	}
+	if err != nil {
+		return offset, err
+	}
+	defer f.Close()
+
+	// The device ignored the range or the .part file was discarded
+	offset = f.Offset
+	if err := part.Truncate(offset); err != nil {
+		return offset, err
+	}
+	if _, err := part.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+	tracker.resume(offset)
+
+	written, err := io.Copy(part, io.TeeReader(f, progressWriter{tracker}))
+	return offset + written, err
+}
+
+// retryDownload reports, whether a failed attempt may succeed, when repeated.
+func retryDownload(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var serr *SystemError
+	return !errors.As(err, &serr)
+}
+
+// fileSize returns the size of the file reported by the directory listing.
+// The listing is fetched page by page, until the file is found.
+func (n *NAS) fileSize(ctx context.Context, remotePath string) (int64, error) {
+	name := path.Base(remotePath)
+
+	it := n.ListDirectoryPages(ctx, path.Dir(remotePath))
+	for it.Next() {
+		for _, f := range it.Page().Files {
+			if f.Filename == name {
+				return int64(f.Size), nil
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("%w: %s", ErrFileNotFound, remotePath)
+}
@@ -0,0 +1,241 @@
+package nas
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/auth"
+)
+
+// rangeBox serves a single file, optionally breaking the first transfers or ignoring ranges.
+type rangeBox struct {
+	data        []byte
+	listed      int // size in the listing, if it differs from data
+	ignoreRange bool
+	breakAfter  int // bytes sent, before the connection is dropped
+	breaks      int // number of transfers to break
+	ranges      []string
+}
+
+func (b *rangeBox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/" + nasURIPath:
+		size := len(b.data)
+		if b.listed != 0 {
+			size = b.listed
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprintf(w, `{"files": [{"filename": "b.bin", "path": "/Backup/b.bin"}, {"filename": "a.bin", "path": "/Backup/a.bin", "size": %d}]}`, size)
+	case "/" + nasFileGetPath:
+		b.ranges = append(b.ranges, r.Header.Get("Range"))
+		if b.ignoreRange {
+			r.Header.Del("Range")
+		}
+		if b.breaks > 0 {
+			b.breaks--
+			w = &breakingWriter{ResponseWriter: w, left: b.breakAfter}
+		}
+		http.ServeContent(w, r, "a.bin", time.Time{}, bytes.NewReader(b.data))
+	}
+}
+
+// breakingWriter drops the connection, once left bytes are written.
+type breakingWriter struct {
+	http.ResponseWriter
+	left int
+}
+
+func (w *breakingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.left {
+		_, _ = w.ResponseWriter.Write(p[:w.left])
+		w.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	w.left -= len(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func TestGetFileRange(t *testing.T) {
+	is := is.New(t)
+
+	box := &rangeBox{data: []byte("0123456789")}
+	ts := httptest.NewServer(box)
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+
+	f, err := n.GetFileRange(context.Background(), "/Backup/a.bin", 2, 3)
+	is.NoErr(err)
+	d, err := io.ReadAll(f)
+	is.NoErr(err)
+	f.Close()
+	is.Equal(string(d), "234")
+	is.Equal(f.Offset, int64(2))
+	is.Equal(f.Size, int64(10))
+	is.Equal(box.ranges[0], "bytes=2-4")
+
+	_, err = n.GetFileRange(context.Background(), "/Backup/a.bin", 20, 0)
+	is.True(errors.Is(err, ErrRangeNotSatisfiable))
+
+	// the whole file is sent, if the device ignores the range
+	box.ignoreRange = true
+	f, err = n.GetFileRange(context.Background(), "/Backup/a.bin", 2, 0)
+	is.NoErr(err)
+	defer f.Close()
+	is.Equal(f.Offset, int64(0))
+	is.Equal(f.Size, int64(10))
+}
+
+func TestDownloadFile(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10000)
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+
+	tests := []struct {
+		name   string
+		box    *rangeBox
+		part   []byte
+		ranges []string
+	}{
+		{
+			name:   "resume after broken transfers",
+			box:    &rangeBox{data: data, breakAfter: 40000, breaks: 2},
+			ranges: []string{"", "bytes=40000-", "bytes=80000-"},
+		},
+		{
+			name:   "resume from part file",
+			box:    &rangeBox{data: data},
+			part:   data[:12345],
+			ranges: []string{"bytes=12345-"},
+		},
+		{
+			name:   "range ignored",
+			box:    &rangeBox{data: data, ignoreRange: true},
+			part:   []byte("stale"),
+			ranges: []string{"bytes=5-"},
+		},
+		{
+			name:   "part file longer than remote file",
+			box:    &rangeBox{data: data},
+			part:   append(bytes.Clone(data), "junk"...),
+			ranges: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			ts := httptest.NewServer(tt.box)
+			defer ts.Close()
+			n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+
+			local := filepath.Join(t.TempDir(), "a.bin")
+			if tt.part != nil {
+				is.NoErr(os.WriteFile(local+".part", tt.part, 0o644))
+			}
+
+			var last Progress
+			err := n.DownloadFile(context.Background(), "/Backup/a.bin", local, DownloadOptions{
+				Progress: func(p Progress) { last = p },
+			})
+			is.NoErr(err)
+
+			got, err := os.ReadFile(local)
+			is.NoErr(err)
+			is.True(bytes.Equal(got, data))
+			_, err = os.Stat(local + ".part")
+			is.True(os.IsNotExist(err))
+
+			is.Equal(tt.box.ranges, tt.ranges)
+			is.True(last.Done)
+			is.Equal(last.Transferred, int64(len(data)))
+		})
+	}
+}
+
+func TestDownloadFileErrors(t *testing.T) {
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+
+	t.Run("not listed", func(t *testing.T) {
+		is := is.New(t)
+
+		ts := httptest.NewServer(&rangeBox{data: []byte("data")})
+		defer ts.Close()
+		n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+
+		err := n.DownloadFile(context.Background(), "/Backup/c.bin", filepath.Join(t.TempDir(), "c.bin"), DownloadOptions{})
+		is.True(errors.Is(err, ErrFileNotFound))
+	})
+
+	t.Run("size mismatch", func(t *testing.T) {
+		is := is.New(t)
+
+		ts := httptest.NewServer(&rangeBox{data: []byte("data"), listed: 10})
+		defer ts.Close()
+		n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+
+		local := filepath.Join(t.TempDir(), "a.bin")
+		err := n.DownloadFile(context.Background(), "/Backup/a.bin", local, DownloadOptions{})
+		is.True(errors.Is(err, ErrSizeMismatch))
+
+		_, err = os.Stat(local)
+		is.True(os.IsNotExist(err))
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		is := is.New(t)
+
+		box := &rangeBox{data: bytes.Repeat([]byte("x"), 100000), breakAfter: 10000, breaks: 5}
+		ts := httptest.NewServer(box)
+		defer ts.Close()
+		n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+
+		local := filepath.Join(t.TempDir(), "a.bin")
+		err := n.DownloadFile(context.Background(), "/Backup/a.bin", local, DownloadOptions{Attempts: 2})
+		is.True(err != nil)
+		is.Equal(len(box.ranges), 2)
+
+		// the next call resumes from the part file
+		part, err := os.Stat(local + ".part")
+		is.NoErr(err)
+		is.Equal(part.Size(), int64(20000))
+	})
+}
+
+func TestDownloadFileLargeDirectory(t *testing.T) {
+	is := is.New(t)
+
+	// IMG_0060.jpg is on the second of three pages and has 60 bytes
+	listing := &cappedBox{total: 120, cap: 50}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+nasURIPath {
+			listing.ServeHTTP(w, r)
+			return
+		}
+		http.ServeContent(w, r, "IMG_0060.jpg", time.Time{}, bytes.NewReader(bytes.Repeat([]byte("x"), 60)))
+	}))
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+
+	local := filepath.Join(t.TempDir(), "IMG_0060.jpg")
+	is.NoErr(n.DownloadFile(context.Background(), "/Bilder/IMG_0060.jpg", local, DownloadOptions{}))
+	is.Equal(listing.requests, 2) // the last page isn't fetched
+
+	fi, err := os.Stat(local)
+	is.NoErr(err)
+	is.Equal(fi.Size(), int64(60))
+}
@@ -2,9 +2,38 @@ package nas
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 )
 
+var (
+	ErrRangeNotSatisfiable = errRangeNotSatisfiable()
+	ErrFileNotFound        = errFileNotFound()
+	ErrSizeMismatch        = errSizeMismatch()
+	ErrUnexpectedPage      = errUnexpectedPage()
+	ErrTransferStalled     = errTransferStalled()
+)
+
+func errRangeNotSatisfiable() error {
+	return errors.New("requested range lies beyond the end of the file")
+}
+
+func errFileNotFound() error {
+	return errors.New("file not found in the directory listing")
+}
+
+func errTransferStalled() error {
+	return errors.New("transfer made no progress within the request timeout")
+}
+
+func errUnexpectedPage() error {
+	return errors.New("device returned another page of the listing than requested")
+}
+
+func errSizeMismatch() error {
+	return errors.New("size of the downloaded file differs from the directory listing")
+}
+
 type SystemError struct {
 	Message string
 	Data    *json.RawMessage
@@ -22,9 +51,24 @@ func (e *SystemError) Error() string {
 }
 
 func (e *SystemError) Unwrap() error {
+	if e.Data == nil {
+		return nil
+	}
+
+	// data is an object, e.g. for create_dir, or a list, e.g. for rename
 	var fse *FileSystemControllerError
-	_ = json.Unmarshal(*e.Data, &fse)
-	return fse
+	if err := json.Unmarshal(*e.Data, &fse); err == nil {
+		if fse == nil {
+			return nil
+		}
+		return fse
+	}
+
+	var list []*FileSystemControllerError
+	if err := json.Unmarshal(*e.Data, &list); err != nil || len(list) == 0 || list[0] == nil {
+		return nil
+	}
+	return list[0]
 }
 
 func (e *FileSystemControllerError) Error() string {
@@ -2,6 +2,7 @@ package nas
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/matryer/is"
@@ -51,3 +52,32 @@ func TestSystemError(t *testing.T) {
 		is.Equal(e.Err.Unwrap().Error(), "Code: 5, Path: /Dokumente, Msg: The folder with the name \"blabla\" already exists and therefore cannot be created.")
 	})
 }
+
+// sampleRenameError is sent by the device, when a rename fails. Unlike other errors, data is a list.
+var sampleRenameError = `{
+	"error": {
+		"message": "[file_system_controller] An error occurred while renaming files or folders.",
+		"data": [
+			{
+				"message": "Because one of the parameters submitted was incorrect, renaming cannot be carried out.",
+				"path": "/Dokumente/2022-0006-baba.pdf",
+				"code": 9
+			}
+		],
+		"code": 400
+	}
+}`
+
+func TestSystemErrorList(t *testing.T) {
+	is := is.New(t)
+
+	var e struct {
+		Err *SystemError `json:"error"`
+	}
+	is.NoErr(json.Unmarshal([]byte(sampleRenameError), &e))
+
+	var fse *FileSystemControllerError
+	is.True(errors.As(e.Err, &fse))
+	is.Equal(fse.Code, 9)
+	is.Equal(fse.Path, "/Dokumente/2022-0006-baba.pdf")
+}
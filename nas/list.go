@@ -0,0 +1,142 @@
+package nas
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultPageSize is the number of entries requested per page of a listing.
+const defaultPageSize = 100
+
+// DirectoryIterator fetches the listing of a directory page by page.
+//
+// Example:
+//
+//	it := n.ListDirectoryPages(ctx, "/Bilder")
+//	for it.Next() {
+//		for _, f := range it.Page().Files {
+//			fmt.Println(f.Path)
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type DirectoryIterator struct {
+	n        *NAS
+	ctx      context.Context
+	path     string
+	pageSize int
+
+	start    int
+	page     *BrowseResponse
+	finished bool
+	err      error
+}
+
+// ListDirectoryPages returns an iterator over the pages of the listing of path.
+// Pages are fetched lazily by Next, so huge directories don't have to fit into memory.
+func (n *NAS) ListDirectoryPages(ctx context.Context, path string) *DirectoryIterator {
+	return n.newDirectoryIterator(ctx, path)
+}
+
+func (n *NAS) newDirectoryIterator(ctx context.Context, path string) *DirectoryIterator {
+	// If no path is provided, we list the root of the storage
+	if path == "" {
+		path = "/"
+	}
+	return &DirectoryIterator{n: n, ctx: ctx, path: path, pageSize: defaultPageSize}
+}
+
+// WithPageSize sets the number of entries requested per page. It must be called before Next.
+func (it *DirectoryIterator) WithPageSize(size int) *DirectoryIterator {
+	if size > 0 {
+		it.pageSize = size
+	}
+	return it
+}
+
+// Next fetches the next page and reports, whether there was one.
+// Once it returns false, Err reports the error, which ended the iteration, if any.
+func (it *DirectoryIterator) Next() bool {
+	if it.finished {
+		return false
+	}
+
+	ctx, op := it.n.startOp(it.ctx, "ListDirectoryPage",
+		attribute.String("fritzos.nas.path", it.path),
+		attribute.Int("fritzos.nas.start", it.start),
+	)
+	ok := it.next(ctx)
+	op.end(it.err)
+	return ok
+}
+
+// Page returns the page fetched by the last call to Next.
+func (it *DirectoryIterator) Page() *BrowseResponse {
+	return it.page
+}
+
+// Err returns the error, which ended the iteration, if any.
+func (it *DirectoryIterator) Err() error {
+	return it.err
+}
+
+func (it *DirectoryIterator) next(ctx context.Context) bool {
+	if it.finished {
+		return false
+	}
+
+	page, err := it.n.listPage(ctx, it.path, it.start, it.pageSize)
+	if err != nil {
+		it.err = err
+		it.finished = true
+		return false
+	}
+
+	// A device ignoring the offset would send the first page over and over again
+	if page.Browse.Index != 0 && page.Browse.Index != it.start+1 {
+		it.err = ErrUnexpectedPage
+		it.finished = true
+		return false
+	}
+
+	entries := len(page.Files) + len(page.Directories)
+	it.start += entries
+	it.page = page
+	switch {
+	case page.Browse.Finished, entries == 0:
+		it.finished = true
+	case page.Browse.TotalCount > 0:
+		it.finished = it.start >= page.Browse.TotalCount
+	default:
+		// Without the paging fields, a short page is the last one. The device may cap
+		// the page size below the requested limit, so it's the last resort.
+		it.finished = entries < it.pageSize
+	}
+	return true
+}
+
+// listPage fetches limit entries of the listing of path, beginning with the 0-based start.
+func (n *NAS) listPage(ctx context.Context, path string, start, limit int) (*BrowseResponse, error) {
+	p := url.Values{}
+	p.Set("sorting", "+filename")
+	p.Set("c", "files")
+	p.Set("a", "browse")
+	p.Set("path", path)
+	p.Set("start", strconv.Itoa(start))
+	p.Set("limit", strconv.Itoa(limit))
+
+	d, err := n.call(ctx, true, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse json
+	var result *BrowseResponse
+	err = json.Unmarshal(d, &result)
+	return result, err
+}
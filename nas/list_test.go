@@ -0,0 +1,141 @@
+package nas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/auth"
+	"github.com/rumenvasilev/go-fritzos/fritztest"
+)
+
+func newPhotoBox(t *testing.T, photos int) *NAS {
+	box := fritztest.NewServer(t).WithUser("fritz1234", "secret")
+	box.Mkdir("/Bilder/Urlaub")
+	for i := 0; i < photos; i++ {
+		box.WriteFile(fmt.Sprintf("/Bilder/IMG_%04d.jpg", i), []byte("jpg"))
+	}
+
+	c := box.NewClient()
+	if err := c.Login("fritz1234", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	return NewWithClient(c)
+}
+
+func TestListDirectoryPaginated(t *testing.T) {
+	is := is.New(t)
+
+	n := newPhotoBox(t, 250)
+
+	res, err := n.ListDirectory("/Bilder")
+	is.NoErr(err)
+	is.Equal(len(res.Files), 250)
+	is.Equal(len(res.Directories), 1)
+	is.Equal(res.Browse.TotalCount, 251)
+	is.True(res.Browse.Finished)
+
+	seen := map[string]bool{}
+	for _, f := range res.Files {
+		is.True(!seen[f.Path]) // no entry is listed twice
+		seen[f.Path] = true
+	}
+}
+
+func TestListDirectoryPages(t *testing.T) {
+	is := is.New(t)
+
+	n := newPhotoBox(t, 25)
+
+	var pages, entries int
+	it := n.ListDirectoryPages(context.Background(), "/Bilder").WithPageSize(10)
+	for it.Next() {
+		pages++
+		entries += len(it.Page().Files) + len(it.Page().Directories)
+		is.Equal(it.Page().Browse.Index, (pages-1)*10+1)
+	}
+	is.NoErr(it.Err())
+	is.Equal(pages, 3)
+	is.Equal(entries, 26)
+	is.True(!it.Next())
+}
+
+// cappedBox lists total files, but never sends more than cap entries per page.
+type cappedBox struct {
+	total, cap int
+	requests   int
+}
+
+func (b *cappedBox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.requests++
+	start, _ := strconv.Atoi(r.FormValue("start"))
+	end := min(start+b.cap, b.total)
+
+	files := []string{}
+	for i := start; i < end; i++ {
+		files = append(files, fmt.Sprintf(`{"filename": "IMG_%04d.jpg", "path": "/Bilder/IMG_%04d.jpg", "size": %d}`, i, i, i))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, `{"files": [%s], "browse": {"path": "/Bilder", "index": %d, "totalCount": %d, "finished": %t, "limit": %d}}`,
+		strings.Join(files, ","), start+1, b.total, end == b.total, b.cap)
+}
+
+func TestListDirectoryCappedPages(t *testing.T) {
+	is := is.New(t)
+
+	box := &cappedBox{total: 120, cap: 50}
+	ts := httptest.NewServer(box)
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+
+	res, err := n.ListDirectory("/Bilder")
+	is.NoErr(err)
+	is.Equal(len(res.Files), 120)
+	is.Equal(res.Files[119].Filename, "IMG_0119.jpg")
+	is.Equal(res.Browse.TotalCount, 120)
+	is.Equal(res.Browse.Limit, 50) // as reported by the device
+	is.True(res.Browse.Finished)
+	is.Equal(box.requests, 3)
+}
+
+func TestListDirectoryPagesErrors(t *testing.T) {
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+
+	t.Run("missing directory", func(t *testing.T) {
+		is := is.New(t)
+
+		n := newPhotoBox(t, 0)
+		it := n.ListDirectoryPages(context.Background(), "/Missing")
+		is.True(!it.Next())
+
+		var serr *SystemError
+		is.True(errors.As(it.Err(), &serr))
+	})
+
+	t.Run("offset ignored", func(t *testing.T) {
+		is := is.New(t)
+
+		// the device sends the first page, whichever page is requested
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			fmt.Fprint(w, `{"files": [{"filename": "a.jpg"}, {"filename": "b.jpg"}], "browse": {"index": 1, "totalCount": 4, "finished": false}}`)
+		}))
+		defer ts.Close()
+		n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+
+		it := n.ListDirectoryPages(context.Background(), "/").WithPageSize(2)
+		is.True(it.Next())
+		is.True(!it.Next())
+		is.True(errors.Is(it.Err(), ErrUnexpectedPage))
+	})
+}
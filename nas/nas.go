@@ -1,7 +1,6 @@
 package nas
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,11 +9,16 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	fritzos "github.com/rumenvasilev/go-fritzos"
 	"github.com/rumenvasilev/go-fritzos/auth"
 	"github.com/rumenvasilev/go-fritzos/request"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -25,22 +29,39 @@ const (
 )
 
 type NAS struct {
-	session *auth.Session
+	client *fritzos.Client
+	// address overrides the address of the client, if set
 	address string
 }
 
+// New creates a NAS with its own client, using the provided session.
 func New(s *auth.Session) *NAS {
-	return &NAS{
-		session: s,
-		address: auth.Address,
-	}
+	return NewWithClient(fritzos.New().WithSession(s))
+}
+
+// NewWithClient creates a NAS, which shares the transport, address, timeouts
+// and session of the provided client. Later changes of the client's address apply to the NAS too.
+func NewWithClient(c *fritzos.Client) *NAS {
+	return &NAS{client: c}
 }
 
+// WithAddress sends the NAS requests to addr instead of the client's address.
+//
+// Deprecated: Login, re-authentication and TLS pinning still use the client's address.
+// Use fritzos.Client.WithAddress instead, which moves all of them.
 func (n *NAS) WithAddress(addr string) *NAS {
 	n.address = addr
 	return n
 }
 
+// addr returns the address of the device.
+func (n *NAS) addr() string {
+	if n.address != "" {
+		return n.address
+	}
+	return n.client.Address()
+}
+
 type BrowseResponse struct {
 	DiskInfo    DiskInfo
 	Files       []File
@@ -102,36 +123,46 @@ func (p *Timestamp) UnmarshalJSON(bytes []byte) error {
 	return nil
 }
 
+// sid returns the id of the client's current session.
+func (n *NAS) sid() string {
+	if s := n.client.Session(); s != nil {
+		return s.String()
+	}
+	return ""
+}
+
 // ListDirectory would call FRITZ API and return the response structure with results
-// or error.
+// or error. Large directories are listed completely, see ListDirectoryPages to process
+// them page by page.
 func (n *NAS) ListDirectory(path string) (*BrowseResponse, error) {
-	fullAddress := fmt.Sprintf("%s/%s", n.address, nasURIPath)
+	rctx, cancel := context.WithTimeout(context.Background(), n.client.Timeouts().Request)
+	defer cancel()
 
-	p := url.Values{}
-	p.Set("sid", n.session.String())
-	p.Set("sorting", "+filename")
-	p.Set("c", "files")
-	p.Set("a", "browse")
-	p.Set("limit", "100")
+	return n.ListDirectoryContext(rctx, path)
+}
 
-	// If no path is provided, we list the root of the storage
-	if path == "" {
-		path = "/"
+// ListDirectoryContext is the same as ListDirectory, but accepts context.
+// The listing is fetched in pages, which are merged into a single response.
+func (n *NAS) ListDirectoryContext(ctx context.Context, path string) (res *BrowseResponse, err error) {
+	ctx, op := n.startOp(ctx, "ListDirectory", attribute.String("fritzos.nas.path", path))
+	defer func() { op.end(err) }()
+
+	it := n.newDirectoryIterator(ctx, path)
+	for it.next(ctx) {
+		page := it.Page()
+		if res == nil {
+			res = page
+			continue
+		}
+		res.Files = append(res.Files, page.Files...)
+		res.Directories = append(res.Directories, page.Directories...)
+		res.DiskInfo = page.DiskInfo
+		res.Browse.Finished = page.Browse.Finished
 	}
-	p.Set("path", path)
-
-	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(30)*time.Second)
-	defer cancel()
-
-	d, err := execute(rctx, fullAddress, strings.NewReader(p.Encode()))
-	if err != nil {
-		return nil, err
+	if it.Err() != nil {
+		return nil, it.Err()
 	}
-
-	// parse json
-	var result *BrowseResponse
-	err = json.Unmarshal(d, &result)
-	return result, err
+	return res, nil
 }
 
 type CreateDirResponse struct {
@@ -139,20 +170,25 @@ type CreateDirResponse struct {
 }
 
 func (n *NAS) CreateDir(name, path string) (*CreateDirResponse, error) {
-	fullAddress := fmt.Sprintf("%s/%s", n.address, nasURIPath)
+	rctx, cancel := context.WithTimeout(context.Background(), n.client.Timeouts().Modify)
+	defer cancel()
+
+	return n.CreateDirContext(rctx, name, path)
+}
+
+// CreateDirContext is the same as CreateDir, but accepts context.
+func (n *NAS) CreateDirContext(ctx context.Context, name, path string) (res *CreateDirResponse, err error) {
+	ctx, op := n.startOp(ctx, "CreateDir", attribute.String("fritzos.nas.path", path), attribute.String("fritzos.nas.name", name))
+	defer func() { op.end(err) }()
 
 	p := url.Values{}
-	p.Set("sid", n.session.String())
 	p.Set("path", path)
 	p.Set("name", name)
 	p.Set("parents", "false") // todo, find out
 	p.Set("c", "files")
 	p.Set("a", "create_dir")
 
-	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(60)*time.Second)
-	defer cancel()
-
-	d, err := execute(rctx, fullAddress, strings.NewReader(p.Encode()))
+	d, err := n.call(ctx, false, p)
 	if err != nil {
 		return nil, err
 	}
@@ -163,45 +199,204 @@ func (n *NAS) CreateDir(name, path string) (*CreateDirResponse, error) {
 	return result, err
 }
 
-// GetFile downloads an object from FRITZ NAS storage
-// Response is the object's data bytes (buffered) or error.
-func (n *NAS) GetFile(path string) (io.ReadCloser, error) {
-	fullAddress := fmt.Sprintf("%s/%s", n.address, nasFileGetPath)
+// GetFile downloads an object from FRITZ NAS storage.
+// The response is streamed, close the returned file to release the connection.
+// Only the wait for the response is limited by the request timeout, not the transfer.
+func (n *NAS) GetFile(path string) (*FileReader, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := time.AfterFunc(n.client.Timeouts().Request, cancel)
+
+	f, err := n.GetFileContext(ctx, path)
+	timer.Stop()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	f.cancel = cancel
+	return f, nil
+}
+
+// GetFileContext is the same as GetFile, but the whole transfer is governed by ctx.
+func (n *NAS) GetFileContext(ctx context.Context, path string) (*FileReader, error) {
+	ctx, op := n.startOp(ctx, "GetFile", attribute.String("fritzos.nas.path", path))
+	return n.getFileOp(ctx, op, path, 0, 0)
+}
+
+// GetFileRange downloads length bytes of an object, starting at offset. A length of zero or less
+// reads up to the end of the file. The device may ignore the range and send the whole file,
+// which is indicated by FileReader.Offset being zero. ErrRangeNotSatisfiable is returned,
+// if offset lies beyond the end of the file.
+func (n *NAS) GetFileRange(ctx context.Context, path string, offset, length int64) (*FileReader, error) {
+	ctx, op := n.startOp(ctx, "GetFileRange",
+		attribute.String("fritzos.nas.path", path),
+		attribute.Int64("fritzos.nas.offset", offset),
+		attribute.Int64("fritzos.nas.length", length),
+	)
+	return n.getFileOp(ctx, op, path, offset, length)
+}
+
+func (n *NAS) getFileOp(ctx context.Context, op *operation, path string, offset, length int64) (*FileReader, error) {
+	var f *FileReader
+	err := n.withSession(ctx, true, func(ctx context.Context, sid string) (err error) {
+		f, err = n.getFile(ctx, sid, path, offset, length)
+		return err
+	})
+	if err != nil {
+		op.end(err)
+		return nil, err
+	}
+
+	// The operation ends, once the transfer is done
+	f.op = op
+	return f, nil
+}
+
+// FileReader streams the content of a file from the NAS storage.
+// Close must be called to release the connection.
+type FileReader struct {
+	body io.ReadCloser
+	// ContentLength is the number of bytes in the response, -1 if unknown.
+	ContentLength int64
+	// Offset is the position of the first byte in the file.
+	Offset int64
+	// Size is the size of the whole file, -1 if unknown.
+	Size        int64
+	ContentType string
+	// LastModified is zero, if the device didn't report it.
+	LastModified time.Time
+
+	op       *operation
+	cancel   context.CancelFunc
+	progress *progressTracker
+	read     int64
+	err      error
+	once     sync.Once
+}
+
+// WithProgress reports the progress of the download to fn. It must be called before reading.
+func (f *FileReader) WithProgress(fn ProgressFunc) *FileReader {
+	f.progress = newProgressTracker(fn, f.ContentLength)
+	return f
+}
+
+func (f *FileReader) Read(p []byte) (int, error) {
+	n, err := f.body.Read(p)
+	f.read += int64(n)
+	f.progress.add(n)
+	if err == io.EOF {
+		f.progress.finish()
+	}
+	if err != nil && err != io.EOF {
+		f.err = err
+	}
+	return n, err
+}
+
+// Close closes the response and ends the session-bound request.
+func (f *FileReader) Close() error {
+	err := f.body.Close()
+	f.once.Do(func() {
+		if f.cancel != nil {
+			f.cancel()
+		}
+		if f.op != nil {
+			f.op.addReceived(f.read)
+			f.op.end(f.err)
+		}
+	})
+	return err
+}
+
+func (n *NAS) getFile(ctx context.Context, sid, path string, offset, length int64) (*FileReader, error) {
+	fullAddress, err := request.JoinURL(n.addr(), nasFileGetPath)
+	if err != nil {
+		return nil, err
+	}
 
 	p := url.Values{}
-	p.Add("sid", n.session.String())
+	p.Add("sid", sid)
 	p.Add("script", fmt.Sprintf("/%s", rootAPI))
 	p.Add("c", "files")
 	p.Add("a", "get")
 	p.Add("path", path)
 
-	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(30)*time.Second)
-	defer cancel()
-	resp, err := request.GenericPostRequestWithContext(rctx, fullAddress, strings.NewReader(p.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullAddress, strings.NewReader(p.Encode()))
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if offset > 0 || length > 0 {
+		req.Header.Set("Range", byteRange(offset, length))
+	}
 
-	d, err := io.ReadAll(resp.Body)
+	resp, err := n.client.Requester().Do(req)
 	if err != nil {
 		return nil, err
 	}
-	resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		// extract the error from the body
-		var e struct {
-			Err SystemError `json:"error"`
-		}
-		errU := json.Unmarshal(d, &e)
-		if errU != nil {
-			return nil, fmt.Errorf("couldn't unmarshal error response, %w", err)
-		}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+	case http.StatusForbidden:
+		resp.Body.Close()
+		return nil, auth.ErrSessionExpired
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return nil, ErrRangeNotSatisfiable
+	default:
+		defer resp.Body.Close()
+		return nil, readError(resp.Body)
+	}
 
-		return nil, &e.Err
+	f := &FileReader{
+		body:          resp.Body,
+		ContentLength: resp.ContentLength,
+		Size:          resp.ContentLength,
+		ContentType:   resp.Header.Get("Content-Type"),
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		f.Offset, f.Size = parseContentRange(resp.Header.Get("Content-Range"))
+	}
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		f.LastModified = lm
 	}
 
-	return io.NopCloser(bytes.NewReader(d)), nil
+	return f, nil
+}
+
+// byteRange returns the value of a Range header, length <= 0 means up to the end.
+func byteRange(offset, length int64) string {
+	if length <= 0 {
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+}
+
+// parseContentRange returns the offset and the complete size from a Content-Range header,
+// e.g. "bytes 100-199/1000". The size is -1, if unknown.
+func parseContentRange(h string) (offset, size int64) {
+	var last int64
+	if _, err := fmt.Sscanf(h, "bytes %d-%d/%d", &offset, &last, &size); err != nil {
+		size = -1
+	}
+	return offset, size
+}
+
+// readError extracts the error from a response body.
+func readError(body io.Reader) error {
+	d, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	var e struct {
+		Err SystemError `json:"error"`
+	}
+	if err := json.Unmarshal(d, &e); err != nil {
+		return fmt.Errorf("couldn't unmarshal error response, %w", err)
+	}
+
+	return &e.Err
 }
 
 // Example:
@@ -236,81 +431,247 @@ const (
 	ResultCodeDirNotExist nasResultCode = "9" // 9 (wrong dir)
 )
 
+// PutFile uploads data to path in the NAS storage.
+// The upload is not replayed, if the session has expired in the meantime. Instead,
+// the client re-authenticates and auth.ErrSessionExpired is returned.
+// The request timeout applies to periods without progress, not to the whole transfer.
+// A stalled upload fails with ErrTransferStalled.
 func (n *NAS) PutFile(path string, data io.Reader) (*PutFileResponse, error) {
-	fullAddress := fmt.Sprintf("%s/%s", n.address, nasFileUploadPath)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
 
-	// Parse path into file and dir
-	p := strings.Split(path, "/")
-	file := p[len(p)-1]
-	dir := strings.Join(p[:len(p)-1], "/")
+	timeout := n.client.Timeouts().Request
+	timer := time.AfterFunc(timeout, func() { cancel(ErrTransferStalled) })
+	defer timer.Stop()
+
+	opts := PutFileOptions{Size: sizeOf(data)}
+	return n.PutFileWithOptions(ctx, path, &stallReader{r: data, timer: timer, timeout: timeout}, opts)
+}
 
-	// Create multipart writer
-	body := bytes.Buffer{}
-	writer := multipart.NewWriter(&body)
+// PutFileContext is the same as PutFile, but the whole transfer is governed by ctx.
+func (n *NAS) PutFileContext(ctx context.Context, path string, data io.Reader) (*PutFileResponse, error) {
+	return n.PutFileWithOptions(ctx, path, data, PutFileOptions{})
+}
 
-	// We need to insert this into the content type
-	params := make(map[string]string)
-	params["sid"] = n.session.String()
-	params["dir"] = dir
-	for key, val := range params {
-		_ = writer.WriteField(key, val)
+// PutFileOptions configures an upload.
+type PutFileOptions struct {
+	// Size is the number of bytes, which data provides. It's sent as Content-Length,
+	// otherwise the upload is chunked. Zero means unknown, unless data reports its size,
+	// like *os.File or *bytes.Reader do.
+	Size int64
+	// Progress, if set, receives the progress of the upload.
+	Progress ProgressFunc
+}
+
+// PutFileWithOptions is the same as PutFileContext, but accepts options.
+// The multipart body is produced while sending, so the memory use doesn't depend on the size of data.
+// If the upload fails or ctx is canceled, the call returns without waiting for a pending read of data,
+// which may still complete afterwards. Close data to release such a reader, e.g. a pipe or connection.
+func (n *NAS) PutFileWithOptions(ctx context.Context, path string, data io.Reader, opts PutFileOptions) (result *PutFileResponse, err error) {
+	ctx, op := n.startOp(ctx, "PutFile", attribute.String("fritzos.nas.path", path))
+	defer func() { op.end(err) }()
+
+	if opts.Size == 0 {
+		opts.Size = sizeOf(data)
+	}
+	if opts.Progress != nil {
+		data = &progressReader{r: data, tracker: newProgressTracker(opts.Progress, opts.Size)}
+	}
+
+	err = n.withSession(ctx, false, func(ctx context.Context, sid string) (err error) {
+		result, err = n.putFile(ctx, sid, path, data, opts.Size)
+		return err
+	})
+	return result, err
+}
+
+// sizeOf returns the remaining size of data, if it can be determined cheaply.
+func sizeOf(data io.Reader) int64 {
+	switch d := data.(type) {
+	case interface{ Len() int }:
+		return int64(d.Len())
+	case *os.File:
+		fi, err := d.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return 0
+		}
+		offset, err := d.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0
+		}
+		return fi.Size() - offset
+	}
+	return 0
+}
+
+// stallReader postpones the timer on every read, which makes progress.
+type stallReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 || err == io.EOF {
+		s.timer.Reset(s.timeout)
+	}
+	return n, err
+}
+
+// uploadForm writes the multipart form of an upload. The fields are written in a fixed order,
+// so the size of the form is known in advance.
+type uploadForm struct {
+	boundary string
+	sid      string
+	dir      string
+	file     string
+}
+
+func (f *uploadForm) write(w io.Writer, data io.Reader) (int64, error) {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(f.boundary); err != nil {
+		return 0, err
+	}
+
+	if err := writer.WriteField("sid", f.sid); err != nil {
+		return 0, err
+	}
+	if err := writer.WriteField("dir", f.dir); err != nil {
+		return 0, err
 	}
 
 	// Add the file metadata
-	w, err := writer.CreateFormFile("UploadFile", file)
+	part, err := writer.CreateFormFile("UploadFile", f.file)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
 	// Finally append the data
-	_, err = io.Copy(w, data)
+	var size int64
+	if data != nil {
+		size, err = io.Copy(part, data)
+		if err != nil {
+			return size, err
+		}
+	}
+
+	return size, writer.Close()
+}
+
+// overhead returns the size of the form without the file data.
+func (f *uploadForm) overhead() (int64, error) {
+	var c countingWriter
+	_, err := f.write(&c, nil)
+	return int64(c), err
+}
+
+// countingReader counts the bytes read from r, it's safe to query from other goroutines.
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+type countingWriter int64
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+func (n *NAS) putFile(ctx context.Context, sid, path string, data io.Reader, size int64) (*PutFileResponse, error) {
+	fullAddress, err := request.JoinURL(n.addr(), nasFileUploadPath)
 	if err != nil {
 		return nil, err
 	}
 
-	writer.Close()
+	// Parse path into file and dir
+	p := strings.Split(path, "/")
+	form := &uploadForm{
+		boundary: multipart.NewWriter(nil).Boundary(),
+		sid:      sid,
+		dir:      strings.Join(p[:len(p)-1], "/"),
+		file:     p[len(p)-1],
+	}
+
+	contentLength := int64(-1)
+	if size > 0 {
+		overhead, err := form.overhead()
+		if err != nil {
+			return nil, err
+		}
+		contentLength = overhead + size
+	}
+
+	// The form is produced, while the transport reads the body. Closing the reader stops
+	// the writer, if the request ended early. The writer isn't waited for, as it may be
+	// blocked reading data, which would defeat the cancellation of ctx.
+	sent := &countingReader{r: data}
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := form.write(pw, sent)
+		pw.CloseWithError(err)
+	}()
+	// The transport waits for its body reader, before it reports the cancellation
+	stop := context.AfterFunc(ctx, func() { pr.CloseWithError(ctx.Err()) })
+	defer func() {
+		stop()
+		pr.Close()
+		operationFromContext(ctx).addSent(sent.n.Load())
+	}()
 
 	// Send the request to the API
-	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(30)*time.Second)
-	defer cancel()
-	req, err := http.NewRequestWithContext(rctx, http.MethodPost, fullAddress, &body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullAddress, pr)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("Content-Type", writer.FormDataContentType())
+	req.ContentLength = contentLength
+	req.Header.Add("Content-Type", "multipart/form-data; boundary="+form.boundary)
 
-	resp, err := request.HttpRequest(req)
+	// Once ctx is done, the transport reports the closed pipe, or the device rejects
+	// the truncated form. Either way, the cancellation is the cause.
+	resp, err := n.client.Requester().Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	if !request.ValidateHeader(request.HeaderJSON, resp.Header) {
-		return nil, errors.New("incorrect response header content-type received")
+	if resp.StatusCode != http.StatusOK && ctx.Err() != nil {
+		return nil, context.Cause(ctx)
 	}
 
-	d, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, auth.ErrSessionExpired
 	}
-	resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		// extract the error from the body
-		var e struct {
-			Err SystemError `json:"error"`
-		}
-		errU := json.Unmarshal(d, &e)
-		if errU != nil {
-			return nil, fmt.Errorf("couldn't unmarshal error response, %w", err)
-		}
+	if !request.ValidateHeader(request.HeaderJSON, resp.Header) {
+		return nil, errors.New("incorrect response header content-type received")
+	}
 
-		return nil, &e.Err
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError(resp.Body)
 	}
 
 	// parse JSON response
 	var result *PutFileResponse
-	err = json.Unmarshal(d, &result)
-	return result, err
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if result.ResultCode == ResultCodeNoSession {
+		return nil, auth.ErrSessionExpired
+	}
+
+	return result, nil
 }
 
 type RenameResponse struct {
@@ -334,14 +695,22 @@ type RenameInput struct {
 // could represent file and/or directory.
 // Response contains how many files have been affected and an error (if any).
 func (n *NAS) RenameObject(params []*RenameInput) (int, error) {
+	rctx, cancel := context.WithTimeout(context.Background(), n.client.Timeouts().Modify)
+	defer cancel()
+
+	return n.RenameObjectContext(rctx, params)
+}
+
+// RenameObjectContext is the same as RenameObject, but accepts context.
+func (n *NAS) RenameObjectContext(ctx context.Context, params []*RenameInput) (count int, err error) {
+	ctx, op := n.startOp(ctx, "RenameObject", attribute.Int("fritzos.nas.objects", len(params)))
+	defer func() { op.end(err) }()
+
 	if len(params) == 0 {
 		return 0, errors.New("no parameters supplied, cannot execute RenameObject command")
 	}
 
-	fullAddress := fmt.Sprintf("%s/%s", n.address, nasURIPath)
-
 	p := url.Values{}
-	p.Add("sid", n.session.String())
 	p.Add("c", "files")
 	p.Add("a", "rename")
 
@@ -350,10 +719,7 @@ func (n *NAS) RenameObject(params []*RenameInput) (int, error) {
 		p.Add(fmt.Sprintf("paths[%d][newName]", k+1), v.To)
 	}
 
-	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(60)*time.Second)
-	defer cancel()
-
-	d, err := execute(rctx, fullAddress, strings.NewReader(p.Encode()))
+	d, err := n.call(ctx, false, p)
 	if err != nil {
 		return 0, err
 	}
@@ -373,10 +739,18 @@ type DeleteResponse struct {
 // that will be deleted from the storage of the NAS.
 // Response contains how many files have been affected and an error (if any).
 func (n *NAS) DeleteObject(paths ...string) (int, error) {
-	fullAddress := fmt.Sprintf("%s/%s", n.address, nasURIPath)
+	rctx, cancel := context.WithTimeout(context.Background(), n.client.Timeouts().Modify)
+	defer cancel()
+
+	return n.DeleteObjectContext(rctx, paths...)
+}
+
+// DeleteObjectContext is the same as DeleteObject, but accepts context.
+func (n *NAS) DeleteObjectContext(ctx context.Context, paths ...string) (count int, err error) {
+	ctx, op := n.startOp(ctx, "DeleteObject", attribute.StringSlice("fritzos.nas.paths", paths))
+	defer func() { op.end(err) }()
 
 	p := url.Values{}
-	p.Add("sid", n.session.String())
 	p.Add("c", "files")
 	p.Add("a", "delete")
 
@@ -384,10 +758,7 @@ func (n *NAS) DeleteObject(paths ...string) (int, error) {
 		p.Add(fmt.Sprintf("paths[%d]", k+1), v)
 	}
 
-	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(60)*time.Second)
-	defer cancel()
-
-	d, err := execute(rctx, fullAddress, strings.NewReader(p.Encode()))
+	d, err := n.call(ctx, false, p)
 	if err != nil {
 		return 0, err
 	}
@@ -406,10 +777,18 @@ type MoveResponse struct {
 // It takes `dest` and a variadic `paths` (a.k.a the source paths to move) string parameter, representing file(s) and/or directory(ies).
 // This is a separate action from rename.
 func (n *NAS) MoveObject(dest string, paths ...string) (int, error) {
-	fullAddress := fmt.Sprintf("%s/%s", n.address, nasURIPath)
+	rctx, cancel := context.WithTimeout(context.Background(), n.client.Timeouts().Modify)
+	defer cancel()
+
+	return n.MoveObjectContext(rctx, dest, paths...)
+}
+
+// MoveObjectContext is the same as MoveObject, but accepts context.
+func (n *NAS) MoveObjectContext(ctx context.Context, dest string, paths ...string) (count int, err error) {
+	ctx, op := n.startOp(ctx, "MoveObject", attribute.String("fritzos.nas.target", dest), attribute.StringSlice("fritzos.nas.paths", paths))
+	defer func() { op.end(err) }()
 
 	p := url.Values{}
-	p.Add("sid", n.session.String())
 	p.Add("c", "files")
 	p.Add("a", "move")
 	p.Add("target", dest)
@@ -418,10 +797,7 @@ func (n *NAS) MoveObject(dest string, paths ...string) (int, error) {
 		p.Add(fmt.Sprintf("paths[%d]", k+1), v)
 	}
 
-	rctx, cancel := context.WithTimeout(context.Background(), time.Duration(60)*time.Second)
-	defer cancel()
-
-	d, err := execute(rctx, fullAddress, strings.NewReader(p.Encode()))
+	d, err := n.call(ctx, false, p)
 	if err != nil {
 		return 0, err
 	}
@@ -436,35 +812,67 @@ func (n *NAS) MoveObject(dest string, paths ...string) (int, error) {
 	return result.MoveCount, nil
 }
 
-// execute will call the API server
-func execute(ctx context.Context, addr string, body io.Reader) ([]byte, error) {
-	res, err := request.GenericPostRequestWithContext(ctx, addr, body)
+// withSession runs op with ctx and the id of the client's current session. If the device
+// reports the session as expired, the client re-authenticates and idempotent
+// operations are replayed once with the new session.
+// Idempotent operations are also retried by the client's retry policy, if any.
+func (n *NAS) withSession(ctx context.Context, idempotent bool, op func(ctx context.Context, sid string) error) error {
+	if idempotent {
+		ctx = request.WithIdempotent(ctx)
+	}
+
+	sid := n.sid()
+	err := op(ctx, sid)
+	if !errors.Is(err, auth.ErrSessionExpired) {
+		return err
+	}
+
+	if rerr := n.client.Reauthenticate(ctx, sid); rerr != nil {
+		return fmt.Errorf("%w, re-authentication failed: %w", err, rerr)
+	}
+
+	if !idempotent {
+		return err
+	}
+
+	return op(ctx, n.sid())
+}
+
+// call will call the API server with the params p, adding the session id to them.
+func (n *NAS) call(ctx context.Context, idempotent bool, p url.Values) ([]byte, error) {
+	addr, err := request.JoinURL(n.addr(), nasURIPath)
 	if err != nil {
 		return nil, err
 	}
 
-	if !request.ValidateHeader(request.HeaderJSON, res.Header) {
-		return nil, errors.New("incorrect response header content-type received")
-	}
+	var d []byte
+	err = n.withSession(ctx, idempotent, func(ctx context.Context, sid string) (err error) {
+		p.Set("sid", sid)
+		d, err = execute(ctx, n.client.Requester(), addr, strings.NewReader(p.Encode()))
+		return err
+	})
+	return d, err
+}
 
-	d, err := io.ReadAll(res.Body)
+// execute will call the API server
+func execute(ctx context.Context, c *request.Client, addr string, body io.Reader) ([]byte, error) {
+	res, err := c.PostWithContext(ctx, addr, body)
 	if err != nil {
 		return nil, err
 	}
-	res.Body.Close()
+	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		// extract the error from the body
-		var e struct {
-			Err SystemError `json:"error"`
-		}
-		errU := json.Unmarshal(d, &e)
-		if errU != nil {
-			return nil, fmt.Errorf("couldn't unmarshal error response, %w", err)
-		}
+	if res.StatusCode == http.StatusForbidden {
+		return nil, auth.ErrSessionExpired
+	}
+
+	if !request.ValidateHeader(request.HeaderJSON, res.Header) {
+		return nil, errors.New("incorrect response header content-type received")
+	}
 
-		return nil, &e.Err
+	if res.StatusCode != http.StatusOK {
+		return nil, readError(res.Body)
 	}
 
-	return d, nil
+	return io.ReadAll(res.Body)
 }
@@ -1,12 +1,26 @@
 package nas
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/matryer/is"
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/auth"
+	"github.com/rumenvasilev/go-fritzos/request"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestUnmarshal(t *testing.T) {
@@ -40,6 +54,459 @@ func TestUnmarshal(t *testing.T) {
 	}
 }
 
+func TestListDirectoryWithClient(t *testing.T) {
+	is := is.New(t)
+
+	fixture, err := os.ReadFile("../testfixtures/nas-browse.json")
+	is.NoErr(err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.URL.Path, "/"+nasURIPath)
+		is.Equal(r.FormValue("sid"), "2c21f7f4f060848e")
+		is.Equal(r.FormValue("path"), "/")
+		is.Equal(r.Header.Get("User-Agent"), fritzos.DefaultUserAgent)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(fixture)
+	}))
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	c := fritzos.New().WithAddress(ts.URL).WithHTTPClient(ts.Client()).WithSession(&sess)
+
+	res, err := NewWithClient(c).ListDirectory("")
+	is.NoErr(err)
+	is.Equal(len(res.Directories), 5)
+	is.Equal(res.Directories[0], dirMockResponse)
+}
+
+func TestRetryPolicy(t *testing.T) {
+	is := is.New(t)
+
+	fixture, err := os.ReadFile("../testfixtures/nas-browse.json")
+	is.NoErr(err)
+
+	attempts := make(map[string]int)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := r.FormValue("a")
+		attempts[action]++
+		// the disk spins up
+		if action == "rename" || attempts[action] == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(fixture)
+	}))
+	defer ts.Close()
+
+	policy := fritzos.DefaultRetryPolicy()
+	policy.Backoff = time.Millisecond
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	c := fritzos.New().WithAddress(ts.URL).WithSession(&sess).WithRetryPolicy(policy)
+	n := NewWithClient(c)
+
+	res, err := n.ListDirectory("/")
+	is.NoErr(err)
+	is.Equal(len(res.Directories), 5)
+	is.Equal(attempts["browse"], 2)
+
+	_, err = n.RenameObject([]*RenameInput{{From: "/a", To: "/b"}})
+	is.True(err != nil)
+	is.Equal(attempts["rename"], 1) // not idempotent
+
+	ctx := request.WithIdempotent(context.Background())
+	_, err = n.RenameObjectContext(ctx, []*RenameInput{{From: "/a", To: "/b"}})
+	is.True(err != nil)
+	is.Equal(attempts["rename"], 1+policy.MaxAttempts) // opted in
+}
+
+func TestTracing(t *testing.T) {
+	is := is.New(t)
+
+	fixture, err := os.ReadFile("../testfixtures/nas-browse.json")
+	is.NoErr(err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		switch r.FormValue("a") {
+		case "create_dir":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(sampleError))
+			return
+		case "rename":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(sampleRenameError))
+			return
+		}
+		_, _ = w.Write(fixture)
+	}))
+	defer ts.Close()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	c := fritzos.New().WithAddress(ts.URL).WithSession(&sess).WithTracerProvider(tp)
+	n := NewWithClient(c)
+
+	_, err = n.ListDirectory("/Dokumente")
+	is.NoErr(err)
+
+	spans := exp.GetSpans()
+	is.Equal(len(spans), 2)
+	is.Equal(spans[0].Name, "HTTP POST")
+	is.Equal(spans[1].Name, "nas.ListDirectory")
+	is.Equal(spans[0].Parent.SpanID(), spans[1].SpanContext.SpanID())
+	is.Equal(spans[1].Attributes[0], attribute.String("fritzos.nas.path", "/Dokumente"))
+
+	exp.Reset()
+	_, err = n.CreateDir("blabla", "/Dokumente")
+	is.True(err != nil)
+
+	spans = exp.GetSpans()
+	is.Equal(len(spans), 2)
+	op := spans[1]
+	is.Equal(op.Name, "nas.CreateDir")
+	is.Equal(op.Status.Code, codes.Error)
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range op.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	is.Equal(attrs["fritzos.error.code"].AsInt64(), int64(400))
+	is.Equal(attrs["fritzos.nas.error.code"].AsInt64(), int64(5))
+
+	// the device reports rename errors as a list
+	exp.Reset()
+	_, err = n.RenameObject([]*RenameInput{{From: "/Dokumente/2022-0006-baba.pdf", To: "a.pdf"}})
+	is.True(err != nil)
+
+	spans = exp.GetSpans()
+	op = spans[len(spans)-1]
+	is.Equal(op.Name, "nas.RenameObject")
+	for _, kv := range op.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	is.Equal(attrs["fritzos.nas.error.code"].AsInt64(), int64(9))
+}
+
+func TestGetFileStreaming(t *testing.T) {
+	is := is.New(t)
+
+	modified := time.Date(2023, 11, 14, 12, 0, 0, 0, time.UTC)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Length", "8")
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		_, _ = w.Write([]byte("part"))
+		w.(http.Flusher).Flush()
+
+		// the transfer outlasts the request timeout
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+	}))
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	c := fritzos.New().WithAddress(ts.URL).WithSession(&sess).WithTimeouts(fritzos.Timeouts{Request: 50 * time.Millisecond})
+
+	f, err := NewWithClient(c).GetFile("/Videos/a.mp4")
+	is.NoErr(err)
+	defer f.Close()
+
+	is.Equal(f.ContentLength, int64(8))
+	is.Equal(f.ContentType, "video/mp4")
+	is.True(f.LastModified.Equal(modified))
+
+	data, err := io.ReadAll(f)
+	is.NoErr(err)
+	is.Equal(string(data), "partdone")
+}
+
+// uploadBox accepts uploads and reports, what it has received.
+type uploadBox struct {
+	contentLength int64
+	dir           string
+	file          string
+	size          int64
+}
+
+func (b *uploadBox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.contentLength = r.ContentLength
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch part.FormName() {
+		case "dir":
+			d, _ := io.ReadAll(part)
+			b.dir = string(d)
+		case "UploadFile":
+			b.file = part.FileName()
+			b.size, _ = io.Copy(io.Discard, part)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, `{"dir": %q, "filename": %q, "done": {"succeeded": "1"}, "ResultCode": "0"}`, b.dir, b.file)
+}
+
+// slowReader provides size bytes in small chunks with a delay in between.
+type slowReader struct {
+	size  int
+	delay time.Duration
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	if s.size == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(s.delay)
+	n := min(len(p), s.size, 1024)
+	s.size -= n
+	return n, nil
+}
+
+func TestPutFileStreaming(t *testing.T) {
+	box := &uploadBox{}
+	ts := httptest.NewServer(box)
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+
+	t.Run("known size", func(t *testing.T) {
+		is := is.New(t)
+		c := fritzos.New().WithAddress(ts.URL).WithSession(&sess)
+
+		size := int64(16 << 20)
+		_, err := NewWithClient(c).PutFileWithOptions(context.Background(), "/Backup/disk.img", io.LimitReader(zeros{}, size), PutFileOptions{Size: size})
+		is.NoErr(err)
+		is.Equal(box.dir, "/Backup")
+		is.Equal(box.file, "disk.img")
+		is.Equal(box.size, size)
+		is.True(box.contentLength > size) // multipart overhead is included
+	})
+
+	t.Run("unknown size", func(t *testing.T) {
+		is := is.New(t)
+		c := fritzos.New().WithAddress(ts.URL).WithSession(&sess)
+
+		_, err := NewWithClient(c).PutFileContext(context.Background(), "/Backup/a.txt", io.MultiReader(strings.NewReader("streamed "), strings.NewReader("data")))
+		is.NoErr(err)
+		is.Equal(box.contentLength, int64(-1)) // chunked
+		is.Equal(box.size, int64(len("streamed data")))
+	})
+
+	t.Run("transfer outlasts the request timeout", func(t *testing.T) {
+		is := is.New(t)
+		c := fritzos.New().WithAddress(ts.URL).WithSession(&sess).WithTimeouts(fritzos.Timeouts{Request: 100 * time.Millisecond})
+
+		_, err := NewWithClient(c).PutFile("/Backup/b.bin", &slowReader{size: 10 << 10, delay: 20 * time.Millisecond})
+		is.NoErr(err)
+		is.Equal(box.size, int64(10<<10))
+	})
+
+	t.Run("stalled transfer", func(t *testing.T) {
+		is := is.New(t)
+		c := fritzos.New().WithAddress(ts.URL).WithSession(&sess).WithTimeouts(fritzos.Timeouts{Request: 50 * time.Millisecond})
+
+		_, err := NewWithClient(c).PutFile("/Backup/c.bin", &slowReader{size: 1, delay: 200 * time.Millisecond})
+		is.True(errors.Is(err, ErrTransferStalled))
+	})
+}
+
+func TestPutFileBlockingReader(t *testing.T) {
+	ts := httptest.NewServer(&uploadBox{})
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	c := fritzos.New().WithAddress(ts.URL).WithSession(&sess).WithTimeouts(fritzos.Timeouts{Request: 100 * time.Millisecond})
+	n := NewWithClient(c)
+
+	t.Run("context", func(t *testing.T) {
+		is := is.New(t)
+
+		// nothing is ever written, reads block until the pipe is closed
+		pr, pw := io.Pipe()
+		defer pw.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := n.PutFileContext(ctx, "/Backup/stdin.bin", pr)
+		is.True(errors.Is(err, context.DeadlineExceeded))
+		is.True(time.Since(start) < time.Second)
+	})
+
+	t.Run("stall timeout", func(t *testing.T) {
+		is := is.New(t)
+
+		pr, pw := io.Pipe()
+		defer pw.Close()
+
+		start := time.Now()
+		_, err := n.PutFile("/Backup/stdin.bin", pr)
+		is.True(errors.Is(err, ErrTransferStalled))
+		is.True(time.Since(start) < time.Second)
+	})
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestErrorResponseReleasesSlot(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	c := fritzos.New().WithAddress(ts.URL).WithSession(&sess).WithRateLimit(fritzos.RateLimit{MaxInFlight: 1})
+	n := NewWithClient(c)
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := n.ListDirectoryContext(ctx, "/")
+		cancel()
+		is.True(err != nil)
+		is.True(!errors.Is(err, context.DeadlineExceeded)) // not stuck waiting for the slot
+	}
+}
+
+func TestClientAddressChange(t *testing.T) {
+	is := is.New(t)
+
+	fixture, err := os.ReadFile("../testfixtures/nas-browse.json")
+	is.NoErr(err)
+
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write(fixture)
+	}))
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	c := fritzos.New().WithSession(&sess)
+	n := NewWithClient(c)
+
+	// the NAS follows the client, e.g. when it's moved to a MyFRITZ address
+	c.WithAddress(ts.URL)
+	_, err = n.ListDirectory("/")
+	is.NoErr(err)
+	is.Equal(requests, 1)
+}
+
+func TestListDirectoryContextCanceled(t *testing.T) {
+	is := is.New(t)
+
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	c := fritzos.New().WithAddress(ts.URL).WithHTTPClient(ts.Client()).WithSession(&sess)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := NewWithClient(c).ListDirectoryContext(ctx, "/")
+	is.True(errors.Is(err, context.DeadlineExceeded))
+}
+
+// expiringBox simulates a device, which accepts only the session of the latest login.
+type expiringBox struct {
+	logins int
+	valid  string
+}
+
+func (b *expiringBox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/login_sid.lua":
+		sid := "0000000000000000"
+		if r.Method == http.MethodPost && r.FormValue("response") != "" {
+			b.logins++
+			b.valid = fmt.Sprintf("%016d", b.logins)
+			sid = b.valid
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, "<SessionInfo><SID>%s</SID><Challenge>1234567z</Challenge><BlockTime>0</BlockTime></SessionInfo>", sid)
+	case "/" + nasURIPath:
+		if r.FormValue("sid") != b.valid {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, `{"renameCount": 1, "browse": {"path": "/"}}`)
+	}
+}
+
+func TestReauthentication(t *testing.T) {
+	is := is.New(t)
+
+	box := &expiringBox{}
+	ts := httptest.NewServer(box)
+	defer ts.Close()
+
+	c := fritzos.New().WithAddress(ts.URL).WithHTTPClient(ts.Client())
+	is.NoErr(c.Login("user", "password"))
+	n := NewWithClient(c)
+
+	t.Run("idempotent operation is replayed", func(t *testing.T) {
+		box.valid = "" // session expired
+		res, err := n.ListDirectory("/")
+		is.NoErr(err)
+		is.Equal(res.Browse.Path, "/")
+		is.Equal(box.logins, 2)
+		is.Equal(c.Session().String(), box.valid)
+	})
+
+	t.Run("non-idempotent operation is not replayed", func(t *testing.T) {
+		box.valid = "" // session expired
+		_, err := n.RenameObject([]*RenameInput{{From: "/a", To: "b"}})
+		is.True(errors.Is(err, auth.ErrSessionExpired))
+		is.Equal(box.logins, 3)
+
+		count, err := n.RenameObject([]*RenameInput{{From: "/a", To: "b"}})
+		is.NoErr(err)
+		is.Equal(count, 1)
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		sess := auth.Session{ID: "2c21f7f4f060848e"}
+		n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+		_, err := n.ListDirectory("/")
+		is.True(errors.Is(err, auth.ErrSessionExpired))
+		is.True(errors.Is(err, fritzos.ErrNoCredentials))
+	})
+}
+
 var dirMockResponse = Directory{
 	Path:        "/Bilder",
 	Shared:      false,
@@ -0,0 +1,84 @@
+package nas
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// operation tracks a NAS operation for the client's tracer and observer.
+type operation struct {
+	name     string
+	start    time.Time
+	span     trace.Span
+	observer fritzos.Observer
+
+	sent, received int64
+}
+
+type operationKey struct{}
+
+// startOp starts the operation name, which is available from the returned context.
+func (n *NAS) startOp(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, *operation) {
+	op := &operation{
+		name:     "nas." + name,
+		start:    time.Now(),
+		observer: n.client.Observer(),
+	}
+
+	ctx, op.span = n.client.Tracer().Start(ctx, op.name, trace.WithAttributes(attrs...))
+	return context.WithValue(ctx, operationKey{}, op), op
+}
+
+// operationFromContext returns the operation started with ctx, or nil.
+func operationFromContext(ctx context.Context) *operation {
+	op, _ := ctx.Value(operationKey{}).(*operation)
+	return op
+}
+
+func (op *operation) addSent(n int64) {
+	if op != nil {
+		op.sent += n
+	}
+}
+
+func (op *operation) addReceived(n int64) {
+	if op != nil {
+		op.received += n
+	}
+}
+
+// end records err, including the error codes reported by the device, and reports the operation.
+func (op *operation) end(err error) {
+	e := fritzos.OperationEvent{
+		Name:          op.name,
+		Duration:      time.Since(op.start),
+		BytesSent:     op.sent,
+		BytesReceived: op.received,
+		Err:           err,
+	}
+
+	var se *SystemError
+	if errors.As(err, &se) {
+		e.ErrorCode = se.Code
+		op.span.SetAttributes(attribute.Int("fritzos.error.code", se.Code))
+		if fse, ok := se.Unwrap().(*FileSystemControllerError); ok {
+			e.ErrorCode = fse.Code
+			op.span.SetAttributes(attribute.Int("fritzos.nas.error.code", fse.Code))
+		}
+	}
+
+	if op.sent > 0 {
+		op.span.SetAttributes(attribute.Int64("fritzos.nas.bytes_sent", op.sent))
+	}
+	if op.received > 0 {
+		op.span.SetAttributes(attribute.Int64("fritzos.nas.bytes_received", op.received))
+	}
+
+	fritzos.EndSpan(op.span, err)
+	op.observer.ObserveOperation(e)
+}
@@ -0,0 +1,136 @@
+package nas
+
+import (
+	"io"
+	"time"
+)
+
+// progressInterval limits how often a ProgressFunc is called during a transfer.
+const progressInterval = 200 * time.Millisecond
+
+// Progress describes the state of a transfer.
+type Progress struct {
+	// Transferred is the number of file bytes sent or received so far.
+	Transferred int64
+	// Total is the size of the file, -1 if unknown.
+	Total int64
+	// Rate is the average transfer rate in bytes per second.
+	Rate float64
+	// ETA is the estimated time until the transfer completes, zero if unknown.
+	ETA time.Duration
+	// Done is set on the last report, once all data has been transferred.
+	Done bool
+}
+
+// Percent returns the completed share of the transfer in the range 0-100, or -1 if the total is unknown.
+func (p Progress) Percent() float64 {
+	if p.Total < 0 {
+		return -1
+	}
+	// The file may have grown since its size was determined
+	if p.Total == 0 || p.Transferred >= p.Total {
+		return 100
+	}
+	return float64(p.Transferred) * 100 / float64(p.Total)
+}
+
+// ProgressFunc receives the progress of a transfer. It's called from the goroutine
+// performing the transfer, so it should return quickly, e.g. by sending to a channel.
+type ProgressFunc func(Progress)
+
+// progressTracker reports the progress of a transfer at most once per progressInterval.
+type progressTracker struct {
+	fn          ProgressFunc
+	total       int64
+	start       time.Time
+	last        time.Time
+	transferred int64
+	resumed     int64
+	done        bool
+}
+
+func newProgressTracker(fn ProgressFunc, total int64) *progressTracker {
+	if fn == nil {
+		return nil
+	}
+	if total <= 0 {
+		total = -1
+	}
+	return &progressTracker{fn: fn, total: total, start: time.Now()}
+}
+
+func (t *progressTracker) add(n int) {
+	if t == nil || t.done {
+		return
+	}
+	t.transferred += int64(n)
+
+	now := time.Now()
+	if now.Sub(t.last) < progressInterval {
+		return
+	}
+	t.last = now
+	t.report(now)
+}
+
+// resume continues the transfer at offset, the bytes before don't count towards the rate.
+func (t *progressTracker) resume(offset int64) {
+	if t == nil {
+		return
+	}
+	t.transferred = offset
+	t.resumed = offset
+	t.start = time.Now()
+}
+
+// finish sends the last report, once the data has been transferred completely.
+func (t *progressTracker) finish() {
+	if t == nil || t.done {
+		return
+	}
+	t.done = true
+	if t.total < 0 {
+		t.total = t.transferred
+	}
+	t.report(time.Now())
+}
+
+func (t *progressTracker) report(now time.Time) {
+	p := Progress{
+		Transferred: t.transferred,
+		Total:       t.total,
+		Done:        t.done,
+	}
+	if elapsed := now.Sub(t.start).Seconds(); elapsed > 0 {
+		p.Rate = float64(t.transferred-t.resumed) / elapsed
+	}
+	if p.Rate > 0 && p.Total > p.Transferred {
+		p.ETA = time.Duration(float64(p.Total-p.Transferred) / p.Rate * float64(time.Second))
+	}
+	t.fn(p)
+}
+
+// progressReader tracks the data read from r.
+type progressReader struct {
+	r       io.Reader
+	tracker *progressTracker
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.tracker.add(n)
+	if err == io.EOF {
+		p.tracker.finish()
+	}
+	return n, err
+}
+
+// progressWriter tracks the data written through it, without finishing the transfer.
+type progressWriter struct {
+	tracker *progressTracker
+}
+
+func (p progressWriter) Write(b []byte) (int, error) {
+	p.tracker.add(len(b))
+	return len(b), nil
+}
@@ -0,0 +1,111 @@
+package nas
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	fritzos "github.com/rumenvasilev/go-fritzos"
+	"github.com/rumenvasilev/go-fritzos/auth"
+)
+
+func TestProgressTracker(t *testing.T) {
+	is := is.New(t)
+
+	var reports []Progress
+	tr := newProgressTracker(func(p Progress) { reports = append(reports, p) }, 100)
+	tr.start = time.Now().Add(-time.Second)
+
+	tr.add(25)
+	tr.add(25) // within the interval, not reported
+	is.Equal(len(reports), 1)
+	is.Equal(reports[0].Transferred, int64(25))
+	is.Equal(reports[0].Total, int64(100))
+	is.Equal(reports[0].Percent(), float64(25))
+	is.True(reports[0].Rate > 0)
+	is.True(reports[0].ETA > 0)
+	is.True(!reports[0].Done)
+
+	tr.add(50)
+	tr.finish()
+	tr.finish()
+	is.Equal(len(reports), 2)
+	is.Equal(reports[1].Transferred, int64(100))
+	is.Equal(reports[1].ETA, time.Duration(0))
+	is.True(reports[1].Done)
+}
+
+func TestProgressPercentExceedsTotal(t *testing.T) {
+	is := is.New(t)
+
+	// e.g. the file grew after it was listed
+	p := Progress{Transferred: 150, Total: 100}
+	is.Equal(p.Percent(), float64(100))
+}
+
+func TestProgressUnknownTotal(t *testing.T) {
+	is := is.New(t)
+
+	var last Progress
+	tr := newProgressTracker(func(p Progress) { last = p }, -1)
+
+	tr.add(10)
+	is.Equal(last.Total, int64(-1))
+	is.Equal(last.Percent(), float64(-1))
+	is.Equal(last.ETA, time.Duration(0))
+
+	tr.finish()
+	is.Equal(last.Total, int64(10))
+	is.True(last.Done)
+}
+
+func TestTransferProgress(t *testing.T) {
+	data := bytes.Repeat([]byte("fritz"), 1<<20)
+
+	box := &uploadBox{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+nasFileUploadPath {
+			box.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(data)
+	}))
+	defer ts.Close()
+
+	sess := auth.Session{ID: "2c21f7f4f060848e"}
+	n := NewWithClient(fritzos.New().WithAddress(ts.URL).WithSession(&sess))
+
+	t.Run("upload", func(t *testing.T) {
+		is := is.New(t)
+
+		var last Progress
+		_, err := n.PutFileWithOptions(context.Background(), "/Backup/a.bin", bytes.NewReader(data), PutFileOptions{
+			Progress: func(p Progress) { last = p },
+		})
+		is.NoErr(err)
+		is.True(last.Done)
+		is.Equal(last.Transferred, int64(len(data)))
+		is.Equal(last.Total, int64(len(data)))
+	})
+
+	t.Run("download", func(t *testing.T) {
+		is := is.New(t)
+
+		f, err := n.GetFile("/Backup/a.bin")
+		is.NoErr(err)
+		defer f.Close()
+
+		var last Progress
+		_, err = io.Copy(io.Discard, f.WithProgress(func(p Progress) { last = p }))
+		is.NoErr(err)
+		is.True(last.Done)
+		is.Equal(last.Transferred, int64(len(data)))
+		is.Equal(last.Total, int64(len(data)))
+	})
+}
@@ -0,0 +1,100 @@
+package fritzos
+
+import (
+	"net/http"
+	"time"
+)
+
+// Observer is notified about the activity of the client and its subsystems,
+// e.g. to record metrics. Implementations must be safe for concurrent use and
+// return quickly, as they are called synchronously.
+type Observer interface {
+	// ObserveRequest is called after every HTTP call to the device.
+	ObserveRequest(RequestEvent)
+	// ObserveOperation is called after every operation of a subsystem, e.g. nas.ListDirectory.
+	ObserveOperation(OperationEvent)
+	// ObserveLogin is called after every login attempt.
+	ObserveLogin(LoginEvent)
+}
+
+// RequestEvent describes an HTTP call to the device.
+type RequestEvent struct {
+	Method string
+	Path   string
+	// Status is the status code of the response, zero if the request failed.
+	Status   int
+	Duration time.Duration
+	Err      error
+}
+
+// OperationEvent describes an operation of a subsystem.
+type OperationEvent struct {
+	// Name is the name of the operation, e.g. nas.PutFile
+	Name     string
+	Duration time.Duration
+	// BytesSent and BytesReceived count the file data transferred, e.g. by PutFile and GetFile.
+	BytesSent     int64
+	BytesReceived int64
+	Err           error
+	// ErrorCode is the error code reported by the device, e.g. the code of a
+	// nas.FileSystemControllerError, zero if there is none.
+	ErrorCode int
+}
+
+// LoginEvent describes a login attempt.
+type LoginEvent struct {
+	Err error
+	// BlockTime is the cooldown enforced by the device, if the attempt was blocked.
+	BlockTime time.Duration
+}
+
+// WithObserver makes the client and its subsystems report their activity to o.
+func (c *Client) WithObserver(o Observer) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observer = o
+	c.hc = nil
+	return c
+}
+
+// Observer returns the observer of the client, one discarding all events unless
+// WithObserver was used.
+func (c *Client) Observer() Observer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.observer == nil {
+		return nopObserver{}
+	}
+	return c.observer
+}
+
+type nopObserver struct{}
+
+func (nopObserver) ObserveRequest(RequestEvent)     {}
+func (nopObserver) ObserveOperation(OperationEvent) {}
+func (nopObserver) ObserveLogin(LoginEvent)         {}
+
+// observingTransport reports every HTTP call to the observer.
+type observingTransport struct {
+	next     http.RoundTripper
+	observer Observer
+}
+
+func (t *observingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	e := RequestEvent{
+		Method:   req.Method,
+		Path:     req.URL.Path,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if resp != nil {
+		e.Status = resp.StatusCode
+	}
+	t.observer.ObserveRequest(e)
+
+	return resp, err
+}
@@ -0,0 +1,117 @@
+package fritzos
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit protects the device's web server from being overwhelmed.
+// Requests exceeding the budget block until they fit or their context is done.
+type RateLimit struct {
+	// RequestsPerSecond limits the rate of requests, zero means unlimited.
+	RequestsPerSecond float64
+	// Burst is the number of requests which may be sent at once, before
+	// RequestsPerSecond applies. Defaults to 1.
+	Burst int
+	// MaxInFlight limits the number of concurrent requests, zero means unlimited.
+	// A request is in flight until its response body is closed.
+	MaxInFlight int
+}
+
+// WithRateLimit limits the requests sent by the client, shared across all
+// subsystems created from it.
+func (c *Client) WithRateLimit(l RateLimit) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limiter = newLimiter(l)
+	c.hc = nil
+	return c
+}
+
+type limiter struct {
+	rate     *rate.Limiter
+	inFlight chan struct{}
+}
+
+func newLimiter(l RateLimit) *limiter {
+	lim := &limiter{}
+
+	if l.RequestsPerSecond > 0 {
+		burst := l.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		lim.rate = rate.NewLimiter(rate.Limit(l.RequestsPerSecond), burst)
+	}
+
+	if l.MaxInFlight > 0 {
+		lim.inFlight = make(chan struct{}, l.MaxInFlight)
+	}
+
+	return lim
+}
+
+func (l *limiter) middleware(next http.RoundTripper) http.RoundTripper {
+	return &limitTransport{next: next, limiter: l}
+}
+
+// limitTransport sends requests only within the budget of the limiter.
+type limitTransport struct {
+	next    http.RoundTripper
+	limiter *limiter
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if t.limiter.rate != nil {
+		if err := t.limiter.rate.Wait(ctx); err != nil {
+			closeBody(req)
+			return nil, err
+		}
+	}
+
+	if t.limiter.inFlight == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	select {
+	case t.limiter.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		closeBody(req)
+		return nil, ctx.Err()
+	}
+
+	release := func() { <-t.limiter.inFlight }
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseBody frees the in-flight slot of its request on Close.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// closeBody closes the body of a request, which isn't sent, as RoundTrip must.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,115 @@
+package fritzos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestRateLimitInFlight(t *testing.T) {
+	is := is.New(t)
+
+	var current, peak atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		defer current.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	c := New().WithAddress(ts.URL).WithRateLimit(RateLimit{MaxInFlight: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.Requester().GetWithContext(context.Background(), ts.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	is.True(peak.Load() <= 2)
+
+	t.Run("blocks until the context is done", func(t *testing.T) {
+		is := is.New(t)
+
+		c := New().WithAddress(ts.URL).WithRateLimit(RateLimit{MaxInFlight: 1})
+
+		// holds the only slot, until its body is closed
+		resp, err := c.Requester().GetWithContext(context.Background(), ts.URL)
+		is.NoErr(err)
+		defer resp.Body.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err = c.Requester().GetWithContext(ctx, ts.URL)
+		is.True(errors.Is(err, context.DeadlineExceeded))
+	})
+}
+
+func TestRateLimitRequestsPerSecond(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	c := New().WithAddress(ts.URL).WithRateLimit(RateLimit{RequestsPerSecond: 50})
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := c.Requester().GetWithContext(context.Background(), ts.URL)
+		is.NoErr(err)
+		resp.Body.Close()
+	}
+
+	// the first request passes immediately, the others wait 20ms each
+	is.True(time.Since(start) >= 80*time.Millisecond)
+}
+
+func TestRateLimitErrorResponse(t *testing.T) {
+	is := is.New(t)
+
+	// the device rejects the first login with an error page
+	var logins atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && logins.Add(1) == 1 {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		sid := "0000000000000000"
+		if r.Method == http.MethodPost {
+			sid = "2c21f7f4f060848e"
+		}
+		_, _ = w.Write([]byte("<SessionInfo><SID>" + sid + "</SID><Challenge>1234567z</Challenge><BlockTime>0</BlockTime></SessionInfo>"))
+	}))
+	defer ts.Close()
+
+	c := New().WithAddress(ts.URL).WithRateLimit(RateLimit{MaxInFlight: 1}).WithTimeouts(Timeouts{Request: time.Second})
+
+	is.True(c.Login("fritz1234", "secret") != nil)
+
+	// the slot of the failed login has been released
+	is.NoErr(c.Login("fritz1234", "secret"))
+}
@@ -0,0 +1,18 @@
+package request
+
+import "context"
+
+type idempotentKey struct{}
+
+// WithIdempotent marks the requests sent with ctx as safe to repeat, so they are
+// retried on transient failures. The device expects POST requests even for
+// read-only operations, so only GET requests are considered idempotent by default.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// IsIdempotent reports whether ctx was marked by WithIdempotent.
+func IsIdempotent(ctx context.Context) bool {
+	v, _ := ctx.Value(idempotentKey{}).(bool)
+	return v
+}
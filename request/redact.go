@@ -0,0 +1,31 @@
+package request
+
+import (
+	"net/url"
+	"regexp"
+)
+
+// Redacted replaces session ids in URLs and messages.
+const Redacted = "REDACTED"
+
+var sidPattern = regexp.MustCompile(`(?i)\bsid=[^&\s"']+`)
+
+// RedactURL returns u without credentials and with the session id replaced,
+// so it can be logged safely.
+func RedactURL(u *url.URL) string {
+	r := *u
+	r.User = nil
+
+	q := r.Query()
+	if q.Has("sid") {
+		q.Set("sid", Redacted)
+		r.RawQuery = q.Encode()
+	}
+
+	return r.String()
+}
+
+// RedactSID replaces all session ids passed as sid parameter in s.
+func RedactSID(s string) string {
+	return sidPattern.ReplaceAllString(s, "sid="+Redacted)
+}
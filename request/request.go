@@ -2,12 +2,66 @@ package request
 
 import (
 	"context"
+	"errors"
 	"io"
-	"log"
 	"net/http"
+	"net/url"
 	"time"
 )
 
+// Client wraps a http.Client, so a single transport (and its connection pool)
+// can be shared between all requests towards the device.
+type Client struct {
+	HTTPClient *http.Client
+	UserAgent  string
+}
+
+// DefaultClient is used by the package level request functions.
+var DefaultClient = &Client{HTTPClient: &http.Client{}}
+
+// Do sends the request, decorating it with the client's common headers.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	resp, err := hc.Do(req)
+	// The error contains the URL, which may carry the session id
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		uerr.URL = RedactSID(uerr.URL)
+	}
+	return resp, err
+}
+
+// GetWithContext calls the target endpoint with a GET request.
+func (c *Client) GetWithContext(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req)
+}
+
+// PostWithContext calls the target endpoint with an url-encoded form POST request.
+func (c *Client) PostWithContext(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "application/x-www-form-urlencoded"
+	req.Header.Set("Content-Type", contentType)
+
+	return c.Do(req)
+}
+
 // genericGetRequest takes session id as parameter and calls the target endpoint
 // Result is plain string, to facilitate development of new requests and structs.
 func GenericGetRequest(url string) (*http.Response, error) {
@@ -19,12 +73,7 @@ func GenericGetRequest(url string) (*http.Response, error) {
 // genericGetRequestWithContext is the same as genericGetRequest, but accepts context
 func GenericGetRequestWithContext(ctx context.Context, url string) (*http.Response, error) {
 	// resp, err := http.Get(fmt.Sprintf("%s&sid=%s", url, Session))
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return HttpRequest(req)
+	return DefaultClient.GetWithContext(ctx, url)
 }
 
 // genericPostRequest takes session id as parameter and calls the target endpoint
@@ -35,21 +84,11 @@ func GenericPostRequest(url string, body io.Reader) (*http.Response, error) {
 
 // genericPostRequestWithContext is the same as genericPostRequest, but accepts context
 func GenericPostRequestWithContext(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
-	if err != nil {
-		return nil, err
-	}
-
-	contentType := "application/x-www-form-urlencoded"
-	req.Header.Set("Content-Type", contentType)
-
-	return HttpRequest(req)
+	return DefaultClient.PostWithContext(ctx, url, body)
 }
 
 func HttpRequest(req *http.Request) (*http.Response, error) {
-	// Set timeout
-	c := &http.Client{}
-	return c.Do(req)
+	return DefaultClient.Do(req)
 }
 
 type ContentType string
@@ -70,7 +109,5 @@ func ValidateHeader(ct ContentType, h http.Header) bool {
 		return false
 	}
 
-	log.Println("Debug:", h.Get("Content-Type"))
-
 	return h.Get("Content-Type") == contentType
 }
@@ -0,0 +1,34 @@
+package request
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// JoinURL appends path, which may carry a query, to the base address of the device.
+// Scheme, port and path prefix of address are kept, so devices behind
+// e.g. https://xyz.myfritz.net:4711 or a reverse proxy at https://example.com/box work.
+func JoinURL(address, path string) (string, error) {
+	base, err := url.Parse(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q, %w", address, err)
+	}
+
+	if base.Scheme == "" || base.Host == "" {
+		return "", fmt.Errorf("invalid address %q, scheme and host are required", address)
+	}
+
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	u := *base
+	u.Path = strings.TrimSuffix(base.Path, "/") + "/" + strings.TrimPrefix(ref.Path, "/")
+	u.RawPath = ""
+	u.RawQuery = ref.RawQuery
+	u.Fragment = ""
+
+	return u.String(), nil
+}
@@ -0,0 +1,32 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestJoinURL(t *testing.T) {
+	is := is.New(t)
+
+	tests := []struct {
+		address string
+		path    string
+		want    string
+	}{
+		{"http://fritz.box", "login_sid.lua?version=2", "http://fritz.box/login_sid.lua?version=2"},
+		{"http://fritz.box/", "nas/api/data.lua", "http://fritz.box/nas/api/data.lua"},
+		{"https://xyz.myfritz.net:4711", "nas/api/data.lua", "https://xyz.myfritz.net:4711/nas/api/data.lua"},
+		{"https://example.com/box/", "/login_sid.lua?version=2&sid=abc", "https://example.com/box/login_sid.lua?version=2&sid=abc"},
+		{"http://[fd00::1]:8080/prefix", "nas/cgi-bin/luacgi_notimeout", "http://[fd00::1]:8080/prefix/nas/cgi-bin/luacgi_notimeout"},
+	}
+
+	for _, tt := range tests {
+		got, err := JoinURL(tt.address, tt.path)
+		is.NoErr(err)
+		is.Equal(got, tt.want)
+	}
+
+	_, err := JoinURL("fritz.box", "login_sid.lua")
+	is.True(err != nil) // scheme missing
+}
@@ -0,0 +1,145 @@
+package fritzos
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/rumenvasilev/go-fritzos/request"
+)
+
+// RetryPolicy repeats idempotent requests, which failed with a connection error or
+// a retryable status code, e.g. while the disk of the device spins up.
+// Requests are idempotent, if they use GET or their context was marked with
+// request.WithIdempotent, like the ones of nas.ListDirectory and nas.GetFile.
+// Pass such a context to other operations (e.g. nas.RenameObjectContext) to opt in.
+type RetryPolicy struct {
+	// MaxAttempts limits the attempts per request, including the first one.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled for each further one.
+	Backoff time.Duration
+	// MaxBackoff limits the wait between attempts, zero means unlimited.
+	MaxBackoff time.Duration
+	// Jitter shortens each wait by a random fraction of up to Jitter (0 to 1),
+	// so clients don't retry in lockstep.
+	Jitter float64
+	// RetryableStatus lists the status codes which are retried.
+	RetryableStatus []int
+}
+
+// DefaultRetryPolicy makes up to 4 attempts, waiting 500ms, 1s and 2s in between.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 4,
+		Backoff:     500 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		Jitter:      0.2,
+		RetryableStatus: []int{
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// WithRetryPolicy makes the client retry idempotent requests according to p.
+func (c *Client) WithRetryPolicy(p *RetryPolicy) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry = p
+	c.hc = nil
+	return c
+}
+
+// wait returns the backoff before the attempt following attempt n (starting at 1).
+func (p *RetryPolicy) wait(n int) time.Duration {
+	d := p.Backoff << (n - 1)
+	if d < 0 || (p.MaxBackoff > 0 && d > p.MaxBackoff) {
+		d = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		d -= time.Duration(p.Jitter * rand.Float64() * float64(d))
+	}
+	return d
+}
+
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	for _, s := range p.RetryableStatus {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryTransport repeats requests according to the policy.
+type retryTransport struct {
+	next   http.RoundTripper
+	policy *RetryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !t.retryable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	r := req
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(r)
+		if err == nil && !t.policy.retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		if attempt >= t.policy.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleep(ctx, t.policy.wait(attempt)); err != nil {
+			return nil, err
+		}
+
+		r = req.Clone(ctx)
+		if req.Body != nil && req.Body != http.NoBody {
+			if r.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// retryable reports whether req may be repeated. Requests with a body can only
+// be repeated, if the body can be recreated.
+func (t *retryTransport) retryable(req *http.Request) bool {
+	if t.policy.MaxAttempts < 2 {
+		return false
+	}
+
+	if req.Method != http.MethodGet && !request.IsIdempotent(req.Context()) {
+		return false
+	}
+
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
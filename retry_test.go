@@ -0,0 +1,61 @@
+package fritzos
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/rumenvasilev/go-fritzos/request"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	is := is.New(t)
+
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		is.Equal(string(body), "a=browse") // body is sent again on every attempt
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	c := New().WithAddress(ts.URL).WithRetryPolicy(&RetryPolicy{
+		MaxAttempts:     3,
+		Backoff:         time.Millisecond,
+		RetryableStatus: []int{http.StatusBadGateway},
+	})
+
+	ctx := request.WithIdempotent(context.Background())
+	resp, err := c.Requester().PostWithContext(ctx, ts.URL, strings.NewReader("a=browse"))
+	is.NoErr(err)
+	resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusBadGateway) // the last response is returned
+	is.Equal(attempts, 3)
+
+	attempts = 0
+	resp, err = c.Requester().PostWithContext(context.Background(), ts.URL, strings.NewReader("a=browse"))
+	is.NoErr(err)
+	resp.Body.Close()
+	is.Equal(attempts, 1) // POST is not retried unless marked idempotent
+}
+
+func TestRetryPolicyWait(t *testing.T) {
+	is := is.New(t)
+
+	p := &RetryPolicy{Backoff: time.Second, MaxBackoff: 3 * time.Second}
+	is.Equal(p.wait(1), time.Second)
+	is.Equal(p.wait(2), 2*time.Second)
+	is.Equal(p.wait(3), 3*time.Second)
+
+	p.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		d := p.wait(1)
+		is.True(d > 500*time.Millisecond && d <= time.Second)
+	}
+}
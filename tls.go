@@ -0,0 +1,252 @@
+package fritzos
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// PinStore keeps the pinned certificate fingerprints of devices, keyed by host.
+type PinStore interface {
+	// Pin returns the pinned fingerprint of host or an empty string, if there is none.
+	Pin(host string) (string, error)
+	SetPin(host, fingerprint string) error
+}
+
+// WithTLSPinning pins the certificate of the device on first use (TOFU) in store,
+// which allows to use https with the device's self-signed certificate.
+// Connections fail with *CertificateMismatchError, once the certificate changes.
+func (c *Client) WithTLSPinning(store PinStore) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tls.pins = store
+	c.hc = nil
+	return c
+}
+
+// WithRootCAs makes the client trust the certificates signed by pool, e.g. the
+// certificate exported from the device (see LoadCertPool).
+func (c *Client) WithRootCAs(pool *x509.CertPool) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tls.rootCAs = pool
+	c.hc = nil
+	return c
+}
+
+// LoadCertPool reads the PEM encoded certificates at path, e.g. the one exported from
+// the device's web interface (Internet > Permit Access > FRITZ!Box Services).
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM encoded certificate found in %s", path)
+	}
+	return pool, nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of cert, in the format the device shows it.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+type tlsOptions struct {
+	pins    PinStore
+	rootCAs *x509.CertPool
+}
+
+func (o tlsOptions) enabled() bool {
+	return o.pins != nil || o.rootCAs != nil
+}
+
+// config returns a copy of base, which verifies the device at address with the options.
+func (o tlsOptions) config(base *tls.Config, address string) (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if base != nil {
+		cfg = base.Clone()
+	}
+
+	if o.rootCAs != nil {
+		cfg.RootCAs = o.rootCAs
+	}
+
+	if o.pins == nil {
+		return cfg, nil
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	roots, pins := o.rootCAs, o.pins
+	// The self-signed certificate can't be verified the usual way, instead
+	// VerifyConnection checks the pin (and the chain, if root CAs are set)
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("device presented no certificate")
+		}
+		leaf := cs.PeerCertificates[0]
+
+		if roots != nil {
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := leaf.Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediates,
+				DNSName:       u.Hostname(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return verifyPin(pins, u.Host, leaf)
+	}
+
+	return cfg, nil
+}
+
+func verifyPin(store PinStore, host string, cert *x509.Certificate) error {
+	got := Fingerprint(cert)
+
+	pinned, err := store.Pin(host)
+	if err != nil {
+		return err
+	}
+
+	// Trust on first use
+	if pinned == "" {
+		return store.SetPin(host, got)
+	}
+
+	if pinned != got {
+		return &CertificateMismatchError{
+			Host:   host,
+			Pinned: pinned,
+			Got:    got,
+		}
+	}
+
+	return nil
+}
+
+// MemoryPinStore keeps the pins in memory, for the lifetime of the process.
+type MemoryPinStore struct {
+	mu   sync.Mutex
+	pins map[string]string
+}
+
+func (m *MemoryPinStore) Pin(host string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pins[host], nil
+}
+
+func (m *MemoryPinStore) SetPin(host, fingerprint string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pins == nil {
+		m.pins = make(map[string]string)
+	}
+	m.pins[host] = fingerprint
+	return nil
+}
+
+// FilePinStore keeps the pins in a file similar to ssh's known_hosts,
+// one "host fingerprint" pair per line, readable only by its owner.
+type FilePinStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFilePinStore creates a store keeping pins at path.
+func NewFilePinStore(path string) *FilePinStore {
+	return &FilePinStore{path: path}
+}
+
+// DefaultPinStorePath returns the location of the pin file in the user's config directory.
+func DefaultPinStorePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "go-fritzos", "known_hosts"), nil
+}
+
+func (f *FilePinStore) Pin(host string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pins, err := f.read()
+	return pins[host], err
+}
+
+func (f *FilePinStore) SetPin(host, fingerprint string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pins, err := f.read()
+	if err != nil {
+		return err
+	}
+	pins[host] = fingerprint
+
+	var b strings.Builder
+	for h, fp := range pins {
+		fmt.Fprintf(&b, "%s %s\n", h, fp)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, []byte(b.String()), 0600)
+}
+
+func (f *FilePinStore) read() (map[string]string, error) {
+	pins := make(map[string]string)
+
+	file, err := os.Open(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return pins, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			pins[fields[0]] = fields[1]
+		}
+	}
+
+	return pins, scanner.Err()
+}
@@ -0,0 +1,67 @@
+package fritzos
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestClientTLSPinning(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewTLSServer(loginHandler(t))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	is.NoErr(err)
+
+	store := NewFilePinStore(filepath.Join(t.TempDir(), "known_hosts"))
+	newClient := func() *Client {
+		return New().WithAddress(ts.URL).WithUserAgent("fritz-test").WithTLSPinning(store)
+	}
+
+	c := newClient()
+	is.NoErr(c.Login("user", testPassword))
+
+	pin, err := store.Pin(u.Host)
+	is.NoErr(err)
+	is.Equal(pin, Fingerprint(ts.Certificate())) // pinned on first use
+
+	// the pin is verified on the next connection
+	is.NoErr(newClient().Login("user", testPassword))
+
+	t.Run("changed certificate", func(t *testing.T) {
+		is := is.New(t)
+
+		is.NoErr(store.SetPin(u.Host, "00:11"))
+
+		err := newClient().Login("user", testPassword)
+		var mismatch *CertificateMismatchError
+		is.True(errors.As(err, &mismatch))
+		is.Equal(mismatch.Pinned, "00:11")
+		is.Equal(mismatch.Got, Fingerprint(ts.Certificate()))
+	})
+}
+
+func TestClientRootCAs(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewTLSServer(loginHandler(t))
+	defer ts.Close()
+
+	// not trusted by default
+	err := New().WithAddress(ts.URL).WithUserAgent("fritz-test").Login("user", testPassword)
+	is.True(err != nil)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+
+	c := New().WithAddress(ts.URL).WithUserAgent("fritz-test").WithRootCAs(pool)
+	is.NoErr(c.Login("user", testPassword))
+	is.Equal(c.Session().String(), testSID)
+}
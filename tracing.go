@@ -0,0 +1,104 @@
+package fritzos
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TracerName is the instrumentation name of the spans created by the SDK.
+const TracerName = "github.com/rumenvasilev/go-fritzos"
+
+// WithTracerProvider makes the client and its subsystems record OpenTelemetry spans
+// for every operation (e.g. Login, nas.ListDirectory) and each underlying HTTP call.
+func (c *Client) WithTracerProvider(tp trace.TracerProvider) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tracer = tp.Tracer(TracerName)
+	c.hc = nil
+	return c
+}
+
+// Tracer returns the tracer used by the client and its subsystems, a no-op one
+// unless WithTracerProvider was used.
+func (c *Client) Tracer() trace.Tracer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.tracer == nil {
+		return noop.NewTracerProvider().Tracer(TracerName)
+	}
+	return c.tracer
+}
+
+// EndSpan records err (if any) in span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracingTransport records a span for every HTTP call, which ends once the
+// response body is closed.
+type tracingTransport struct {
+	next   http.RoundTripper
+	tracer trace.Tracer
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	if req.ContentLength > 0 {
+		span.SetAttributes(attribute.Int64("http.request.body.size", req.ContentLength))
+	}
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		EndSpan(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+// spanBody counts the bytes of a response body and ends its span on Close.
+type spanBody struct {
+	io.ReadCloser
+	span trace.Span
+	n    int64
+	once sync.Once
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.span.SetAttributes(attribute.Int64("http.response.body.size", b.n))
+		b.span.End()
+	})
+	return err
+}
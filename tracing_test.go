@@ -0,0 +1,42 @@
+package fritzos
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/matryer/is"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestClientTracing(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(loginHandler(t))
+	defer ts.Close()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	c := New().WithAddress(ts.URL).WithUserAgent("fritz-test").WithTracerProvider(tp)
+	is.NoErr(c.Login("user", testPassword))
+
+	spans := exp.GetSpans()
+	is.Equal(len(spans), 3)
+	is.Equal(spans[0].Name, "HTTP GET")  // challenge
+	is.Equal(spans[1].Name, "HTTP POST") // response
+	is.Equal(spans[2].Name, "Login")
+
+	login := spans[2]
+	for _, s := range spans[:2] {
+		is.Equal(s.Parent.SpanID(), login.SpanContext.SpanID())
+
+		attrs := make(map[string]any)
+		for _, kv := range s.Attributes {
+			attrs[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		is.Equal(attrs["url.path"], "/login_sid.lua")
+		is.Equal(attrs["http.response.status_code"], int64(200))
+		is.True(attrs["http.response.body.size"].(int64) > 0)
+	}
+}
@@ -0,0 +1,103 @@
+package fritzos
+
+import (
+	"errors"
+	"net/http"
+)
+
+// buildHTTPClient applies the transport options to a copy of the configured http.Client.
+// Must be called with c.mu held.
+func (c *Client) buildHTTPClient() *http.Client {
+	hc := *c.httpClient
+
+	rt := hc.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	if c.tls.enabled() || c.proxy != nil || c.dial != nil {
+		t, ok := rt.(*http.Transport)
+		if !ok {
+			hc.Transport = errTransport{errors.New("tls, proxy and dial options require the transport to be a *http.Transport")}
+			return &hc
+		}
+
+		t = t.Clone()
+		if err := c.configureTransport(t); err != nil {
+			hc.Transport = errTransport{err}
+			return &hc
+		}
+		rt = t
+	}
+
+	hc.Transport = chain(rt, c.middlewares()...)
+	return &hc
+}
+
+// middlewares returns the built-in middlewares enabled by the options, followed by
+// the custom ones, the outermost first.
+func (c *Client) middlewares() []Middleware {
+	mws := []Middleware{RedactSIDMiddleware}
+
+	// Retry outside of the rate limit and logging, so every attempt is limited and logged
+	if c.retry != nil {
+		mws = append(mws, RetryMiddleware(c.retry))
+	}
+
+	// Limit outside of logging, so logged durations don't include waits for the budget
+	if c.limiter != nil {
+		mws = append(mws, c.limiter.middleware)
+	}
+
+	// Trace inside of the retry, so every attempt gets its own span
+	if tracer := c.tracer; tracer != nil {
+		mws = append(mws, func(next http.RoundTripper) http.RoundTripper {
+			return &tracingTransport{next: next, tracer: tracer}
+		})
+	}
+
+	if observer := c.observer; observer != nil {
+		mws = append(mws, func(next http.RoundTripper) http.RoundTripper {
+			return &observingTransport{next: next, observer: observer}
+		})
+	}
+
+	mws = append(mws, c.middleware...)
+
+	if c.logger != nil {
+		mws = append(mws, LoggingMiddleware(c.logger))
+	}
+
+	return mws
+}
+
+// configureTransport applies the tls, proxy and dial options to t.
+func (c *Client) configureTransport(t *http.Transport) error {
+	if c.proxy != nil {
+		t.Proxy = http.ProxyURL(c.proxy)
+	}
+
+	if c.dial != nil {
+		t.DialContext = c.dial
+	}
+
+	if c.tls.enabled() {
+		cfg, err := c.tls.config(t.TLSClientConfig, c.address)
+		if err != nil {
+			return err
+		}
+		t.TLSClientConfig = cfg
+	}
+
+	return nil
+}
+
+// errTransport fails every request with err, which happened while building the transport.
+type errTransport struct {
+	err error
+}
+
+func (e errTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	closeBody(req)
+	return nil, e.err
+}
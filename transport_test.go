@@ -0,0 +1,64 @@
+package fritzos
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestClientPathPrefix(t *testing.T) {
+	is := is.New(t)
+
+	mux := http.NewServeMux()
+	mux.Handle("/box/", http.StripPrefix("/box", loginHandler(t)))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := New().WithAddress(ts.URL + "/box/").WithUserAgent("fritz-test")
+	is.NoErr(c.Login("user", testPassword))
+	is.Equal(c.Session().String(), testSID)
+}
+
+func TestClientProxy(t *testing.T) {
+	is := is.New(t)
+
+	// The proxy receives the requests with the absolute address of the device
+	var hosts []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.URL.Host)
+		loginHandler(t)(w, r)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	is.NoErr(err)
+
+	c := New().WithAddress("http://xyz.myfritz.net:4711").WithUserAgent("fritz-test").WithProxy(proxyURL)
+	is.NoErr(c.Login("user", testPassword))
+	is.Equal(hosts, []string{"xyz.myfritz.net:4711", "xyz.myfritz.net:4711"})
+}
+
+func TestClientDialContext(t *testing.T) {
+	is := is.New(t)
+
+	ts := httptest.NewServer(loginHandler(t))
+	defer ts.Close()
+
+	// Connect to the test server, whatever the address of the device is
+	var dials atomic.Int32
+	dial := func(ctx context.Context, network, _ string) (net.Conn, error) {
+		dials.Add(1)
+		var d net.Dialer
+		return d.DialContext(ctx, network, ts.Listener.Addr().String())
+	}
+
+	c := New().WithAddress("http://fritz.box").WithUserAgent("fritz-test").WithDialContext(dial)
+	is.NoErr(c.Login("user", testPassword))
+	is.True(dials.Load() > 0)
+}